@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+type followReturn struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type followListReturn struct {
+	Count      int64          `json:"count"`
+	Users      []followReturn `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid user id"})
+		return
+	}
+
+	if followeeID == userID {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "you can't follow yourself"})
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUserByID(r.Context(), followeeID); errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	if err := cfg.dbQueries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't follow user: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid user id"})
+		return
+	}
+
+	if err := cfg.dbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't unfollow user: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, cfg.dbQueries.CountFollowers, func(ctx context.Context, arg database.ListFollowersParams) ([]followReturn, error) {
+		rows, err := cfg.dbQueries.ListFollowers(ctx, arg)
+		resp := make([]followReturn, 0, len(rows))
+		for _, v := range rows {
+			resp = append(resp, followReturn(v))
+		}
+		return resp, err
+	})
+}
+
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, cfg.dbQueries.CountFollowing, func(ctx context.Context, arg database.ListFollowersParams) ([]followReturn, error) {
+		rows, err := cfg.dbQueries.ListFollowing(ctx, database.ListFollowingParams(arg))
+		resp := make([]followReturn, 0, len(rows))
+		for _, v := range rows {
+			resp = append(resp, followReturn(v))
+		}
+		return resp, err
+	})
+}
+
+// listFollows serves both directions of the follow graph, which only differ in
+// the queries they run.
+func (cfg *apiConfig) listFollows(
+	w http.ResponseWriter,
+	r *http.Request,
+	count func(context.Context, uuid.UUID) (int64, error),
+	list func(context.Context, database.ListFollowersParams) ([]followReturn, error),
+) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid user id"})
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	listParams := database.ListFollowersParams{
+		UserID:    userID,
+		PageLimit: int32(limit + 1),
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorFollowedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	total, err := count(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't count follows: %s", err))
+		return
+	}
+
+	users, err := list(r.Context(), listParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't list follows: %s", err))
+		return
+	}
+
+	nextCursor := ""
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		nextCursor = encodeCursor(last.FollowedAt, last.UserID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	respondWithJSON(w, http.StatusOK, followListReturn{Count: total, Users: users, NextCursor: nextCursor})
+}
+
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	listParams := database.ListTimelineParams{
+		UserID:    userID,
+		PageLimit: int32(limit + 1),
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	// each followed account contributes at most a page of its newest chirps,
+	// read from the per-author index, and the newest of those make the page
+	chirps, err := cfg.dbQueries.ListTimeline(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	resp, err := cfg.chirpsToReturn(r.Context(), chirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: resp, NextCursor: nextCursor})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/entities"
+)
+
+const (
+	trendingInterval = time.Minute
+	// uses are counted at the time the chirp was posted. Older than the window
+	// they're ignored, and within it every use loses half its weight each
+	// half-life, so a burst of recent chirps outranks a tag that was busy at
+	// the start of the window
+	trendingWindow   = 24 * time.Hour
+	trendingHalfLife = 2 * time.Hour
+	maxTrendingTags  = 20
+)
+
+type trendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+	Uses  int64   `json:"uses"`
+}
+
+type trendingReturn struct {
+	ComputedAt time.Time     `json:"computed_at"`
+	Tags       []trendingTag `json:"tags"`
+}
+
+// trendingCache holds the latest ranking computed by the background job, so
+// requests never touch the hashtag tables.
+type trendingCache struct {
+	mu         sync.RWMutex
+	tags       []trendingTag
+	computedAt time.Time
+}
+
+func (c *trendingCache) set(tags []trendingTag, computedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tags = tags
+	c.computedAt = computedAt
+}
+
+func (c *trendingCache) get() trendingReturn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return trendingReturn{ComputedAt: c.computedAt, Tags: c.tags}
+}
+
+// indexHashtags records the hashtags in body against the chirp. It replaces
+// whatever was indexed before, so it is safe to call again after an edit.
+func indexHashtags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	if err := q.DeleteChirpHashtags(ctx, chirpID); err != nil {
+		return fmt.Errorf("couldn't clear hashtags: %s", err)
+	}
+
+	for _, tag := range entities.Hashtags(body) {
+		hashtag, err := q.UpsertHashtag(ctx, entities.NormalizeHashtag(tag.Text))
+		if err != nil {
+			return fmt.Errorf("couldn't save hashtag: %s", err)
+		}
+
+		if err := q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirpID,
+			HashtagID: hashtag.ID,
+		}); err != nil {
+			return fmt.Errorf("couldn't tag chirp: %s", err)
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeHashtag(r.PathValue("tag"))
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	viewerID := cfg.optionalUserID(r)
+	listParams := database.ListChirpsByHashtagParams{
+		Tag:       tag,
+		ViewerID:  viewerID,
+		PageLimit: int32(limit + 1),
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.ListChirpsByHashtag(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	resp, err := cfg.chirpsToReturn(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: resp, NextCursor: nextCursor})
+}
+
+func (cfg *apiConfig) getTrending(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, cfg.trending.get())
+}
+
+// runTrendingJob recomputes the trending ranking every trendingInterval until
+// ctx is cancelled.
+func (cfg *apiConfig) runTrendingJob(ctx context.Context) {
+	ticker := time.NewTicker(trendingInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.refreshTrending(ctx); err != nil {
+			log.Printf("couldn't compute trending hashtags: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) refreshTrending(ctx context.Context) error {
+	rows, err := cfg.dbQueries.ComputeTrendingHashtags(ctx, database.ComputeTrendingHashtagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		WindowSeconds:   trendingWindow.Seconds(),
+		MaxTags:         maxTrendingTags,
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := make([]trendingTag, 0, len(rows))
+	for _, v := range rows {
+		tags = append(tags, trendingTag(v))
+	}
+
+	cfg.trending.set(tags, time.Now().UTC())
+	return nil
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
@@ -24,44 +25,97 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Issuer:    "chirpy",
-	}
+const (
+	// Issuer is the iss claim of every token chirpy signs.
+	Issuer = "chirpy"
+	// Audience is the aud claim of every token chirpy signs: they are only
+	// good for calling the chirpy API.
+	Audience = "chirpy-api"
+)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// PurposeTwoFactor marks a challenge token: proof that someone got the
+// password right, which is only good for finishing a two-factor sign in.
+const PurposeTwoFactor = "2fa"
 
-	signedToken, err := token.SignedString([]byte(tokenSecret))
+// ErrWrongPurpose is returned when a token is valid but was issued for
+// something else, such as a challenge token used as an access token.
+var ErrWrongPurpose = errors.New("token was issued for a different purpose")
 
-	return signedToken, err
+// Claims is the payload of the tokens MakeJWT and MakeChallengeJWT sign.
+type Claims struct {
+	Role Role `json:"role,omitempty"`
+	// Purpose is empty on access tokens.
+	Purpose string `json:"purpose,omitempty"`
+	// SessionID is the session an access token was issued to, when there is
+	// one.
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Session returns the session the token was issued to. Tokens issued before
+// sessions existed, or outside of one, have none.
+func (c *Claims) Session() uuid.NullUUID {
+	id, err := uuid.Parse(c.SessionID)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := &jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
-	if err != nil || !token.Valid {
-		return uuid.Nil, err
+// MakeJWT returns an access token signed with the keyring's signing key.
+// sessionID may be uuid.Nil for a token that doesn't belong to a session.
+func MakeJWT(userID uuid.UUID, role Role, sessionID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	claims := Claims{Role: role}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
 	}
-	userID, err := uuid.Parse(claims.Subject)
+	return signJWT(claims, userID, keys, expiresIn)
+}
+
+// MakeChallengeJWT returns a token that Validator.ValidateChallenge accepts
+// and every access token check refuses.
+func MakeChallengeJWT(userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	return signJWT(Claims{Purpose: PurposeTwoFactor}, userID, keys, expiresIn)
+}
+
+func signJWT(claims Claims, userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	key, err := keys.signingKey()
 	if err != nil {
-		return uuid.Nil, err
+		return "", err
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{Audience},
 	}
-	return userID, nil
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+
+	signedToken, err := token.SignedString(key.Private)
+
+	return signedToken, err
 }
 
+// ErrNoToken and ErrBadAuthorizationHeader are returned by GetBearerToken.
+var (
+	ErrNoToken                = errors.New("failed to get autorization")
+	ErrBadAuthorizationHeader = errors.New("incorrect autorization string format")
+)
+
 func GetBearerToken(headers http.Header) (string, error) {
 	auth := headers.Get("Authorization")
 	if auth == "" {
-		return "", errors.New("failed to get autorization")
+		return "", ErrNoToken
 	}
 	parts := strings.Split(auth, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", errors.New("incorrect autorization string format")
+		return "", ErrBadAuthorizationHeader
 	}
 	return parts[1], nil
 }
@@ -76,14 +130,22 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// HashToken returns the form a token from MakeRefreshToken is stored in, so a
+// leaked table doesn't hand out working tokens. The tokens are random and
+// long, so unlike passwords a fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetAPIKey(headers http.Header) (string, error) {
 	auth := headers.Get("Authorization")
 	if auth == "" {
-		return "", errors.New("failed to get autorization")
+		return "", ErrNoToken
 	}
 	parts := strings.Split(auth, " ")
 	if len(parts) != 2 || parts[0] != "ApiKey" {
-		return "", errors.New("incorrect autorization string format")
+		return "", ErrBadAuthorizationHeader
 	}
 	return parts[1], nil
 }
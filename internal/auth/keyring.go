@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned for a token signed with a key the keyring
+// doesn't hold, either because it was retired or because it was never ours.
+var ErrUnknownKey = errors.New("token was signed with an unknown key")
+
+// ErrNoSigningKey is returned when a token is made before any keys are loaded.
+var ErrNoSigningKey = errors.New("no signing key loaded")
+
+// SigningKey is an Ed25519 key tokens are signed with. ID goes in the kid
+// header of every token it signs, so verifiers know which key to check with.
+type SigningKey struct {
+	ID        string
+	Private   ed25519.PrivateKey
+	CreatedAt time.Time
+}
+
+// GenerateSigningKey returns a new random key.
+func GenerateSigningKey(createdAt time.Time) (SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return newSigningKey(private, createdAt), nil
+}
+
+// SigningKeyFromSeed rebuilds a key from the seed Seed returned, such as one
+// read back from storage.
+func SigningKeyFromSeed(seed []byte, createdAt time.Time) (SigningKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return SigningKey{}, fmt.Errorf("signing key seed is %d bytes, want %d", len(seed), ed25519.SeedSize)
+	}
+	return newSigningKey(ed25519.NewKeyFromSeed(seed), createdAt), nil
+}
+
+func newSigningKey(private ed25519.PrivateKey, createdAt time.Time) SigningKey {
+	public := private.Public().(ed25519.PublicKey)
+	return SigningKey{
+		ID:        thumbprint(public),
+		Private:   private,
+		CreatedAt: createdAt,
+	}
+}
+
+// Seed is the part of the key worth storing; SigningKeyFromSeed derives the
+// rest from it.
+func (k SigningKey) Seed() []byte {
+	return k.Private.Seed()
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func publicJWK(id string, public ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(public),
+		KeyID:     id,
+		Algorithm: "EdDSA",
+		Use:       "sig",
+	}
+}
+
+// thumbprint is the RFC 7638 thumbprint of a public key, used as its key id.
+// The JSON members have to be in this exact order for the hash to match
+// anyone else's.
+func thumbprint(public ed25519.PublicKey) string {
+	canonical := fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, base64.RawURLEncoding.EncodeToString(public))
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Keyring holds the keys tokens are signed and checked with. Every key it
+// holds verifies tokens, but only one signs them. It is safe for concurrent
+// use, including while it is being loaded.
+type Keyring struct {
+	mu      sync.RWMutex
+	signing SigningKey
+	public  map[string]ed25519.PublicKey
+}
+
+// NewKeyring returns a keyring holding keys, as Load would leave it.
+func NewKeyring(keys []SigningKey, now time.Time, publishDelay time.Duration) *Keyring {
+	k := &Keyring{}
+	k.Load(keys, now, publishDelay)
+	return k
+}
+
+// Load replaces every key in the keyring. The newest key that is at least
+// publishDelay old signs: other servers and anyone caching the JWKS get that
+// long to pick a key up before tokens signed with it turn up. If every key is
+// newer than that, as on first start, the oldest of them signs.
+func (k *Keyring) Load(keys []SigningKey, now time.Time, publishDelay time.Duration) {
+	sorted := append([]SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var signing SigningKey
+	public := make(map[string]ed25519.PublicKey, len(sorted))
+	for i, key := range sorted {
+		public[key.ID] = key.Private.Public().(ed25519.PublicKey)
+		if i == 0 || !key.CreatedAt.After(now.Add(-publishDelay)) {
+			signing = key
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.signing = signing
+	k.public = public
+}
+
+// SigningKeyID returns the id of the key new tokens are signed with, or "" if
+// there isn't one.
+func (k *Keyring) SigningKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.signing.ID
+}
+
+func (k *Keyring) signingKey() (SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signing.Private == nil {
+		return SigningKey{}, ErrNoSigningKey
+	}
+	return k.signing, nil
+}
+
+func (k *Keyring) publicKey(id string) (ed25519.PublicKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	public, ok := k.public[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return public, nil
+}
+
+// JWKS returns the public half of every key, for other services to check
+// tokens with. Keys are in id order so the document only changes when the
+// keys do.
+func (k *Keyring) JWKS() JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(k.public))}
+	for id, public := range k.public {
+		set.Keys = append(set.Keys, publicJWK(id, public))
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+	return set
+}
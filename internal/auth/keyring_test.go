@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	key, err := GenerateSigningKey(time.Now())
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %s", err)
+	}
+	return NewKeyring([]SigningKey{key}, time.Now(), 0)
+}
+
+func testSigningKey(t *testing.T, createdAt time.Time) SigningKey {
+	t.Helper()
+	key, err := GenerateSigningKey(createdAt)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %s", err)
+	}
+	return key
+}
+
+func TestSigningKeyFromSeed(t *testing.T) {
+	key := testSigningKey(t, time.Now())
+
+	restored, err := SigningKeyFromSeed(key.Seed(), key.CreatedAt)
+	if err != nil {
+		t.Fatalf("SigningKeyFromSeed returned an error: %s", err)
+	}
+	if restored.ID != key.ID || !restored.Private.Equal(key.Private) {
+		t.Fatalf("SigningKeyFromSeed returned key %s, want %s", restored.ID, key.ID)
+	}
+
+	if _, err := SigningKeyFromSeed([]byte("too short"), key.CreatedAt); err == nil {
+		t.Fatalf("SigningKeyFromSeed accepted a short seed")
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// the Ed25519 example from RFC 8037, appendix A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatalf("couldn't decode key: %s", err)
+	}
+	if got, want := thumbprint(ed25519.PublicKey(x)), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Fatalf("thumbprint = %q, want %q", got, want)
+	}
+}
+
+func TestKeyringSigningKey(t *testing.T) {
+	now := time.Now()
+	publishDelay := 5 * time.Minute
+	old := testSigningKey(t, now.Add(-48*time.Hour))
+	published := testSigningKey(t, now.Add(-time.Hour))
+	fresh := testSigningKey(t, now.Add(-time.Minute))
+
+	tests := []struct {
+		name string
+		keys []SigningKey
+		want string
+	}{
+		{"newest published key signs", []SigningKey{fresh, old, published}, published.ID},
+		{"unpublished key waits", []SigningKey{old, fresh}, old.ID},
+		{"oldest key signs on first start", []SigningKey{fresh, testSigningKey(t, now)}, fresh.ID},
+		{"no keys", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := NewKeyring(tt.keys, now, publishDelay)
+			if got := keys.SigningKeyID(); got != tt.want {
+				t.Fatalf("SigningKeyID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	old := testSigningKey(t, now.Add(-48*time.Hour))
+	keys := NewKeyring([]SigningKey{old}, now, 0)
+
+	userID := uuid.New()
+	before, err := MakeJWT(userID, RoleUser, uuid.Nil, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+
+	next := testSigningKey(t, now)
+	keys.Load([]SigningKey{old, next}, now, 0)
+	if got := keys.SigningKeyID(); got != next.ID {
+		t.Fatalf("SigningKeyID() = %q after rotating, want %q", got, next.ID)
+	}
+
+	// tokens signed before the rotation still verify while the old key is held
+	after, err := MakeJWT(userID, RoleUser, uuid.Nil, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+	for _, token := range []string{before, after} {
+		if got, err := testValidator(t, keys).Validate(token); err != nil || got != userID {
+			t.Fatalf("Validate returned %s, %v", got, err)
+		}
+	}
+
+	keys.Load([]SigningKey{next}, now, 0)
+	if _, err := testValidator(t, keys).Validate(before); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Validate accepted a token from a retired key: %v", err)
+	}
+}
+
+func TestKeyringEmpty(t *testing.T) {
+	keys := NewKeyring(nil, time.Now(), 0)
+	if _, err := MakeJWT(uuid.New(), RoleUser, uuid.Nil, keys, time.Hour); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("MakeJWT signed a token without a key: %v", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	now := time.Now()
+	first := testSigningKey(t, now.Add(-time.Hour))
+	second := testSigningKey(t, now)
+	keys := NewKeyring([]SigningKey{second, first}, now, 0)
+
+	set := keys.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS returned %d keys, want 2", len(set.Keys))
+	}
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.Algorithm != "EdDSA" || jwk.Use != "sig" {
+			t.Fatalf("JWKS returned %+v", jwk)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			t.Fatalf("couldn't decode x: %s", err)
+		}
+		if thumbprint(ed25519.PublicKey(x)) != jwk.KeyID {
+			t.Fatalf("kid %q doesn't match its key", jwk.KeyID)
+		}
+	}
+	if set.Keys[0].KeyID > set.Keys[1].KeyID {
+		t.Fatalf("JWKS keys aren't in id order")
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeyEncryptionKeySize is how many bytes of key a KeySealer takes.
+const KeyEncryptionKeySize = 32
+
+// ErrSealedKeyInvalid is returned for a stored key that can't be opened:
+// it was sealed with another key, altered, or moved to another id.
+var ErrSealedKeyInvalid = errors.New("sealed signing key couldn't be opened")
+
+// KeySealer encrypts signing keys for storage with AES-256-GCM, so reading
+// the database alone isn't enough to sign tokens.
+type KeySealer struct {
+	aead cipher.AEAD
+}
+
+// ParseKeyEncryptionKey decodes a base64 key of KeyEncryptionKeySize bytes,
+// as kept in the environment.
+func ParseKeyEncryptionKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("key encryption key isn't base64: %w", err)
+	}
+	if len(key) != KeyEncryptionKeySize {
+		return nil, fmt.Errorf("key encryption key is %d bytes, want %d", len(key), KeyEncryptionKeySize)
+	}
+	return key, nil
+}
+
+// NewKeySealer returns a sealer that encrypts with key.
+func NewKeySealer(key []byte) (*KeySealer, error) {
+	if len(key) != KeyEncryptionKeySize {
+		return nil, fmt.Errorf("key encryption key is %d bytes, want %d", len(key), KeyEncryptionKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySealer{aead: aead}, nil
+}
+
+// Seal encrypts k's seed. The key id is bound in as additional data, so a
+// sealed seed only opens under the id it was stored with.
+func (s *KeySealer) Seal(k SigningKey) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, k.Seed(), []byte(k.ID)), nil
+}
+
+// Open decrypts a seed Seal returned for the key with the given id and
+// rebuilds the key.
+func (s *KeySealer) Open(id string, sealed []byte, createdAt time.Time) (SigningKey, error) {
+	if len(sealed) < s.aead.NonceSize() {
+		return SigningKey{}, ErrSealedKeyInvalid
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	seed, err := s.aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return SigningKey{}, ErrSealedKeyInvalid
+	}
+
+	key, err := SigningKeyFromSeed(seed, createdAt)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	if key.ID != id {
+		return SigningKey{}, fmt.Errorf("signing key %s is stored under the wrong id %s", key.ID, id)
+	}
+	return key, nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testKeySealer(t *testing.T) *KeySealer {
+	t.Helper()
+	key := make([]byte, KeyEncryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("couldn't make key: %s", err)
+	}
+	sealer, err := NewKeySealer(key)
+	if err != nil {
+		t.Fatalf("NewKeySealer returned an error: %s", err)
+	}
+	return sealer
+}
+
+func TestKeySealerRoundTrip(t *testing.T) {
+	sealer := testKeySealer(t)
+	key := testSigningKey(t, time.Now())
+
+	sealed, err := sealer.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal returned an error: %s", err)
+	}
+	if bytes.Contains(sealed, key.Seed()) {
+		t.Fatalf("sealed key contains the plaintext seed")
+	}
+
+	opened, err := sealer.Open(key.ID, sealed, key.CreatedAt)
+	if err != nil {
+		t.Fatalf("Open returned an error: %s", err)
+	}
+	if opened.ID != key.ID || !opened.Private.Equal(key.Private) {
+		t.Fatalf("Open returned key %s, want %s", opened.ID, key.ID)
+	}
+}
+
+func TestKeySealerRejects(t *testing.T) {
+	sealer := testKeySealer(t)
+	key := testSigningKey(t, time.Now())
+	other := testSigningKey(t, time.Now())
+
+	sealed, err := sealer.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal returned an error: %s", err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name   string
+		sealer *KeySealer
+		id     string
+		sealed []byte
+	}{
+		{"another encryption key", testKeySealer(t), key.ID, sealed},
+		{"moved to another id", sealer, other.ID, sealed},
+		{"altered", sealer, key.ID, tampered},
+		{"truncated", sealer, key.ID, sealed[:4]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.sealer.Open(tt.id, tt.sealed, key.CreatedAt); !errors.Is(err, ErrSealedKeyInvalid) {
+				t.Fatalf("Open returned %v, want ErrSealedKeyInvalid", err)
+			}
+		})
+	}
+}
+
+func TestParseKeyEncryptionKey(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(make([]byte, KeyEncryptionKeySize))
+	if _, err := ParseKeyEncryptionKey(valid); err != nil {
+		t.Fatalf("ParseKeyEncryptionKey returned an error: %s", err)
+	}
+
+	for _, s := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := ParseKeyEncryptionKey(s); err == nil {
+			t.Errorf("ParseKeyEncryptionKey(%q) accepted a bad key", s)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Role is what a user is allowed to do. Each role can do everything the
+// roles below it can.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// ErrInsufficientRole is for callers to return when a valid token's user
+// doesn't hold the role an endpoint needs.
+var ErrInsufficientRole = errors.New("caller doesn't hold the required role")
+
+var roleRanks = map[Role]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ParseRole checks s is one of the known roles.
+func ParseRole(s string) (Role, error) {
+	if _, ok := roleRanks[Role(s)]; !ok {
+		return "", fmt.Errorf("role must be %q, %q or %q", RoleUser, RoleModerator, RoleAdmin)
+	}
+	return Role(s), nil
+}
+
+// Includes reports whether r grants everything required does. Unknown roles,
+// including the empty one, grant nothing.
+func (r Role) Includes(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// IsStaff reports whether r is a moderator or above. Staff accounts can't be
+// suspended or shadow-banned; their role has to be taken away first.
+func (r Role) IsStaff() bool {
+	return r.Includes(RoleModerator)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTPMatchesRFC6238(t *testing.T) {
+	// the SHA-1 test vectors from RFC 6238, appendix B
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		if got := hotp(key, uint64(step), 8); got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret, err := MakeTOTPSecret()
+	if err != nil {
+		t.Fatalf("MakeTOTPSecret returned an error: %s", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("TOTPCode returned an error: %s", err)
+	}
+	if step, ok := MatchTOTP(secret, code, now); !ok || step != TOTPStep(now) {
+		t.Fatalf("MatchTOTP(current code) = %d, %v", step, ok)
+	}
+
+	// a code from the previous period still works, to allow for drift
+	if _, ok := MatchTOTP(secret, code, now.Add(TOTPPeriod)); !ok {
+		t.Fatalf("MatchTOTP rejected a code one period old")
+	}
+	if _, ok := MatchTOTP(secret, code, now.Add(3*TOTPPeriod)); ok {
+		t.Fatalf("MatchTOTP accepted a code three periods old")
+	}
+	if _, ok := MatchTOTP(secret, "12345", now); ok {
+		t.Fatalf("MatchTOTP accepted a code of the wrong length")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	got := TOTPURI("JBSWY3DPEHPK3PXP", "Chirpy", "user@example.com")
+	want := "otpauth://totp/Chirpy:user@example.com?algorithm=SHA1&digits=6&issuer=Chirpy&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Fatalf("TOTPURI returned %q, want %q", got, want)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	code, err := MakeRecoveryCode()
+	if err != nil {
+		t.Fatalf("MakeRecoveryCode returned an error: %s", err)
+	}
+	if len(code) != 19 || strings.Count(code, "-") != 3 {
+		t.Fatalf("MakeRecoveryCode returned %q", code)
+	}
+
+	retyped := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+	if NormalizeRecoveryCode(retyped) != NormalizeRecoveryCode(code) {
+		t.Fatalf("NormalizeRecoveryCode(%q) != NormalizeRecoveryCode(%q)", retyped, code)
+	}
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// The errors a Validator returns, besides ErrWrongPurpose. Every failure
+// wraps exactly one of them, along with the underlying cause, so callers can
+// tell them apart with errors.Is.
+var (
+	ErrMalformedToken   = errors.New("token is malformed")
+	ErrBadSignature     = errors.New("token signature is invalid")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrBadIssuer        = errors.New("token has the wrong issuer")
+	ErrBadAudience      = errors.New("token is for a different audience")
+	ErrMissingClaim     = errors.New("token is missing a required claim")
+)
+
+// ErrUnknownSubject is for callers to return when a valid token names a user
+// that no longer exists.
+var ErrUnknownSubject = errors.New("token's user no longer exists")
+
+// tokenErrors maps the jwt package's errors onto ours, in the order they are
+// checked when a token fails several ways at once.
+var tokenErrors = []struct {
+	cause error
+	err   error
+}{
+	{jwt.ErrTokenMalformed, ErrMalformedToken},
+	{jwt.ErrTokenUnverifiable, ErrBadSignature},
+	{jwt.ErrTokenSignatureInvalid, ErrBadSignature},
+	{jwt.ErrTokenExpired, ErrTokenExpired},
+	{jwt.ErrTokenNotValidYet, ErrTokenNotYetValid},
+	{jwt.ErrTokenUsedBeforeIssued, ErrTokenNotYetValid},
+	{jwt.ErrTokenInvalidIssuer, ErrBadIssuer},
+	{jwt.ErrTokenInvalidAudience, ErrBadAudience},
+	{jwt.ErrTokenRequiredClaimMissing, ErrMissingClaim},
+}
+
+// claimPresent reports whether each claim ValidatorOptions.RequiredClaims can
+// name is set.
+var claimPresent = map[string]func(c *Claims) bool{
+	"exp": func(c *Claims) bool { return c.ExpiresAt != nil },
+	"nbf": func(c *Claims) bool { return c.NotBefore != nil },
+	"iat": func(c *Claims) bool { return c.IssuedAt != nil },
+	"iss": func(c *Claims) bool { return c.Issuer != "" },
+	"aud": func(c *Claims) bool { return len(c.Audience) > 0 },
+	"sub": func(c *Claims) bool { return c.Subject != "" },
+	"jti": func(c *Claims) bool { return c.ID != "" },
+	"sid": func(c *Claims) bool { return c.SessionID != "" },
+}
+
+// ValidatorOptions says what a Validator accepts.
+type ValidatorOptions struct {
+	// Algorithms lists the alg headers accepted. The keyring only holds
+	// Ed25519 keys, so EdDSA is the only one there is any point listing, and
+	// it is what an empty list means.
+	Algorithms []string
+	// Issuer and Audience are the iss and aud claims tokens must carry. An
+	// empty one isn't checked.
+	Issuer   string
+	Audience string
+	// Leeway is how far apart the clocks of the server that signed a token
+	// and the one checking it can be, for exp, nbf and iat.
+	Leeway time.Duration
+	// RequiredClaims names claims tokens must have: any of exp, nbf, iat, iss,
+	// aud, sub, jti and sid.
+	RequiredClaims []string
+}
+
+// Validator checks tokens signed with a keyring's keys. It is safe for
+// concurrent use.
+type Validator struct {
+	keys     *Keyring
+	parser   *jwt.Parser
+	required []string
+}
+
+// NewValidator returns a validator that checks tokens against keys and opts.
+func NewValidator(keys *Keyring, opts ValidatorOptions) (*Validator, error) {
+	algorithms := opts.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{jwt.SigningMethodEdDSA.Alg()}
+	}
+	for _, alg := range algorithms {
+		if alg != jwt.SigningMethodEdDSA.Alg() {
+			return nil, fmt.Errorf("algorithm %q can't be checked with an Ed25519 keyring", alg)
+		}
+	}
+
+	for _, claim := range opts.RequiredClaims {
+		if _, ok := claimPresent[claim]; !ok {
+			return nil, fmt.Errorf("unknown claim %q", claim)
+		}
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithIssuedAt(),
+	}
+	if opts.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(opts.Audience))
+	}
+
+	return &Validator{
+		keys:     keys,
+		parser:   jwt.NewParser(parserOptions...),
+		required: opts.RequiredClaims,
+	}, nil
+}
+
+// Parse checks an access token and returns everything it claims. Tokens
+// issued before roles existed come back with an empty Role.
+func (v *Validator) Parse(tokenString string) (*Claims, error) {
+	return v.parse(tokenString, "")
+}
+
+// Validate checks an access token and returns the user it was issued to.
+func (v *Validator) Validate(tokenString string) (uuid.UUID, error) {
+	return v.subject(tokenString, "")
+}
+
+// ValidateChallenge checks a token from MakeChallengeJWT and returns the user
+// it was issued to.
+func (v *Validator) ValidateChallenge(tokenString string) (uuid.UUID, error) {
+	return v.subject(tokenString, PurposeTwoFactor)
+}
+
+func (v *Validator) subject(tokenString, purpose string) (uuid.UUID, error) {
+	claims, err := v.parse(tokenString, purpose)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: subject: %w", ErrMalformedToken, err)
+	}
+	return userID, nil
+}
+
+func (v *Validator) parse(tokenString, purpose string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.publicKey(kid)
+	})
+	if err != nil {
+		return nil, tokenError(err)
+	}
+
+	for _, claim := range v.required {
+		if !claimPresent[claim](claims) {
+			return nil, fmt.Errorf("%w: %s", ErrMissingClaim, claim)
+		}
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrWrongPurpose
+	}
+	return claims, nil
+}
+
+// tokenError wraps err, from the jwt package, with the matching error of ours.
+func tokenError(err error) error {
+	for _, e := range tokenErrors {
+		if errors.Is(err, e.cause) {
+			return fmt.Errorf("%w: %w", e.err, err)
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrMalformedToken, err)
+}
+
+// describedErrors are the failures WWWAuthenticate tells the client about.
+var describedErrors = []error{
+	ErrMalformedToken,
+	ErrBadSignature,
+	ErrTokenExpired,
+	ErrTokenNotYetValid,
+	ErrBadIssuer,
+	ErrBadAudience,
+	ErrMissingClaim,
+	ErrWrongPurpose,
+	ErrUnknownSubject,
+}
+
+// WWWAuthenticate returns the WWW-Authenticate header to send with a request
+// rejected because of err, as RFC 6750 describes. A request without a token
+// just learns that one is needed; the rest are told what was wrong with the
+// one they sent.
+func WWWAuthenticate(realm string, err error) string {
+	challenge := fmt.Sprintf("Bearer realm=%q", realm)
+
+	switch {
+	case errors.Is(err, ErrBadAuthorizationHeader):
+		return challenge + `, error="invalid_request"`
+	case errors.Is(err, ErrInsufficientRole):
+		return challenge + `, error="insufficient_scope"`
+	}
+	for _, described := range describedErrors {
+		if errors.Is(err, described) {
+			return challenge + fmt.Sprintf(`, error="invalid_token", error_description=%q`, described.Error())
+		}
+	}
+	return challenge
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func testValidator(t *testing.T, keys *Keyring) *Validator {
+	t.Helper()
+	v, err := NewValidator(keys, ValidatorOptions{
+		Issuer:         Issuer,
+		Audience:       Audience,
+		Leeway:         time.Minute,
+		RequiredClaims: []string{"exp", "iat", "sub"},
+	})
+	if err != nil {
+		t.Fatalf("NewValidator returned an error: %s", err)
+	}
+	return v
+}
+
+// signClaims signs claims with the keyring's key, for tokens MakeJWT would
+// never make.
+func signClaims(t *testing.T, keys *Keyring, claims Claims) string {
+	t.Helper()
+	key, err := keys.signingKey()
+	if err != nil {
+		t.Fatalf("keyring has no signing key: %s", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+	return signed
+}
+
+func TestValidatorErrors(t *testing.T) {
+	keys := testKeyring(t)
+	v := testValidator(t, keys)
+	userID := uuid.New()
+	now := time.Now()
+
+	registered := func(change func(c *jwt.RegisteredClaims)) Claims {
+		c := jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			Issuer:    Issuer,
+			Audience:  jwt.ClaimStrings{Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		}
+		change(&c)
+		return Claims{RegisteredClaims: c}
+	}
+
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, registered(func(c *jwt.RegisteredClaims) {})).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, registered(func(c *jwt.RegisteredClaims) {})).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to make token: %s", err)
+	}
+	otherKeyring, err := MakeJWT(userID, RoleUser, uuid.Nil, testKeyring(t), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {})), nil},
+		{"not a token", "not.a.token", ErrMalformedToken},
+		{"HS256", hs256, ErrBadSignature},
+		{"alg none", unsigned, ErrBadSignature},
+		{"unknown key", otherKeyring, ErrUnknownKey},
+		{"expired", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Minute))
+		})), ErrTokenExpired},
+		{"expired within leeway", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-30 * time.Second))
+		})), nil},
+		{"not valid yet", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+		})), ErrTokenNotYetValid},
+		{"issued in the future", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.IssuedAt = jwt.NewNumericDate(now.Add(time.Hour))
+		})), ErrTokenNotYetValid},
+		{"wrong issuer", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.Issuer = "someone-else"
+		})), ErrBadIssuer},
+		{"wrong audience", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.Audience = jwt.ClaimStrings{"another-api"}
+		})), ErrBadAudience},
+		{"no audience", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.Audience = nil
+		})), ErrMissingClaim},
+		{"no expiry", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = nil
+		})), ErrMissingClaim},
+		{"bad subject", signClaims(t, keys, registered(func(c *jwt.RegisteredClaims) {
+			c.Subject = "someone"
+		})), ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Validate(tt.token)
+			if tt.want == nil {
+				if err != nil || got != userID {
+					t.Fatalf("Validate returned %s, %v", got, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Validate returned %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorBadSignatureWrapsUnknownKey(t *testing.T) {
+	token, err := MakeJWT(uuid.New(), RoleUser, uuid.Nil, testKeyring(t), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+	if _, err := testValidator(t, testKeyring(t)).Validate(token); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("Validate returned %v, want %v", err, ErrBadSignature)
+	}
+}
+
+func TestValidatorRequiredSession(t *testing.T) {
+	keys := testKeyring(t)
+	v, err := NewValidator(keys, ValidatorOptions{RequiredClaims: []string{"sid"}})
+	if err != nil {
+		t.Fatalf("NewValidator returned an error: %s", err)
+	}
+
+	token, err := MakeJWT(uuid.New(), RoleUser, uuid.Nil, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+	if _, err := v.Validate(token); !errors.Is(err, ErrMissingClaim) {
+		t.Fatalf("Validate returned %v, want %v", err, ErrMissingClaim)
+	}
+
+	token, err = MakeJWT(uuid.New(), RoleUser, uuid.New(), keys, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+	if _, err := v.Validate(token); err != nil {
+		t.Fatalf("Validate returned an error: %s", err)
+	}
+}
+
+func TestNewValidatorOptions(t *testing.T) {
+	keys := testKeyring(t)
+	if _, err := NewValidator(keys, ValidatorOptions{Algorithms: []string{"RS256"}}); err == nil {
+		t.Errorf("NewValidator accepted an algorithm the keyring can't check")
+	}
+	if _, err := NewValidator(keys, ValidatorOptions{RequiredClaims: []string{"name"}}); err == nil {
+		t.Errorf("NewValidator accepted an unknown claim")
+	}
+	if _, err := NewValidator(keys, ValidatorOptions{Algorithms: []string{"EdDSA"}}); err != nil {
+		t.Errorf("NewValidator returned an error: %s", err)
+	}
+}
+
+func TestWWWAuthenticate(t *testing.T) {
+	keys := testKeyring(t)
+	expired, err := MakeJWT(uuid.New(), RoleUser, uuid.Nil, keys, -time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make jwt: %s", err)
+	}
+	_, expiredErr := testValidator(t, keys).Validate(expired)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"no token", ErrNoToken, `Bearer realm="chirpy"`},
+		{"bad header", ErrBadAuthorizationHeader, `Bearer realm="chirpy", error="invalid_request"`},
+		{"expired", expiredErr, `Bearer realm="chirpy", error="invalid_token", error_description="token has expired"`},
+		{"wrong purpose", ErrWrongPurpose, `Bearer realm="chirpy", error="invalid_token", error_description="token was issued for a different purpose"`},
+		{"deleted user", fmt.Errorf("%w: user %s", ErrUnknownSubject, uuid.New()), `Bearer realm="chirpy", error="invalid_token", error_description="token's user no longer exists"`},
+		{"role", ErrInsufficientRole, `Bearer realm="chirpy", error="insufficient_scope"`},
+	}
+
+	for _, tt := range tests {
+		if got := WWWAuthenticate("chirpy", tt.err); got != tt.want {
+			t.Errorf("%s: WWWAuthenticate = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -17,22 +17,171 @@ type Chirp struct {
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.NullUUID
+	InReplyTo uuid.NullUUID
+	RechirpOf uuid.NullUUID
+	QuoteOf   uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	CreatedAt   time.Time
+	Handle      string
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	Body      string
+}
+
+type ChirpSearch struct {
+	ChirpID  uuid.UUID
+	Document interface{}
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type FilteredTerm struct {
+	Term      string
+	CreatedAt time.Time
+	Action    string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ModerationAuditLog struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	ActorID      uuid.NullUUID
+	Action       string
+	ReportID     uuid.NullUUID
+	ChirpID      uuid.NullUUID
+	TargetUserID uuid.NullUUID
+	Details      string
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type RecoveryCode struct {
+	CodeHash  string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
-	Token     string
+	TokenHash string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.NullUUID
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
+	RotatedAt sql.NullTime
 }
 
-type User struct {
-	ID             uuid.UUID
+type Report struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ChirpID       uuid.NullUUID
+	ChirpAuthorID uuid.NullUUID
+	ReporterID    uuid.NullUUID
+	Reason        string
+	Details       string
+	Status        string
+	ClaimedBy     uuid.NullUUID
+	ClaimedAt     sql.NullTime
+	ResolvedBy    uuid.NullUUID
+	ResolvedAt    sql.NullTime
+	Resolution    sql.NullString
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.NullUUID
+	Kind      string
+	Details   string
+}
+
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	UserAgent  string
+	Ip         string
+}
+
+type SigningKey struct {
+	ID                  string
+	EncryptedPrivateKey []byte
+	CreatedAt           time.Time
+}
+
+type TotpCredential struct {
+	UserID         uuid.UUID
+	Secret         string
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	EnabledAt      sql.NullTime
+	LastStep       int64
+	FailedAttempts int32
+	LastFailedAt   sql.NullTime
+}
+
+type User struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	HashedPassword  string
+	IsChirpyRed     bool
+	Handle          string
+	DisplayName     string
+	Bio             string
+	Location        string
+	SuspendedAt     sql.NullTime
+	ShadowBannedAt  sql.NullTime
+	Role            string
+	EmailVerifiedAt sql.NullTime
 }
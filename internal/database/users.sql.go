@@ -13,24 +13,33 @@ import (
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES (
         gen_random_uuid(),
         NOW(),
         NOW(),
         $1,
-        $2
+        $2,
+        $3,
+        $4
     )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, rechirp_of, quote_of
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.NullUUID
+	Body      string
+	UserID    uuid.NullUUID
+	InReplyTo uuid.NullUUID
+	QuoteOf   uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -38,40 +47,51 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.RechirpOf,
+		&i.QuoteOf,
 	)
 	return i, err
 }
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens(token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens(token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
 VALUES (
     $1,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
-
+    NULL,
+    $4
 )
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
+	TokenHash string
 	UserID    uuid.NullUUID
 	ExpiresAt time.Time
+	FamilyID  uuid.UUID
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.FamilyID,
+	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
@@ -82,25 +102,44 @@ INSERT INTO users (
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle,
+        display_name,
+        bio,
+        location
     )
 VALUES (
         gen_random_uuid(),
         NOW(),
         NOW(),
         $1,
-        $2
+        $2,
+        $3,
+        $4,
+        $5,
+        $6
     )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         string
+	DisplayName    string
+	Bio            string
+	Location       string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -109,6 +148,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -137,43 +184,8 @@ func (q *Queries) EmailExists(ctx context.Context, email string) (int32, error)
 	return column_1, err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id
-FROM chirps
-ORDER BY created_at ASC
-`
-
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Chirp
-	for rows.Next() {
-		var i Chirp
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Body,
-			&i.UserID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, rechirp_of, quote_of
 FROM chirps
 WHERE id = $1
 `
@@ -187,32 +199,88 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.RechirpOf,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const getProfileByHandle = `-- name: GetProfileByHandle :one
+SELECT id,
+    created_at,
+    handle,
+    display_name,
+    bio,
+    location,
+    is_chirpy_red,
+    (
+        SELECT COUNT(*)
+        FROM follows
+        WHERE followee_id = users.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*)
+        FROM follows
+        WHERE follower_id = users.id
+    ) AS following_count
+FROM users
+WHERE LOWER(handle) = LOWER($1::text)
+`
+
+type GetProfileByHandleRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Handle         string
+	DisplayName    string
+	Bio            string
+	Location       string
+	IsChirpyRed    bool
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetProfileByHandle(ctx context.Context, handle string) (GetProfileByHandleRow, error) {
+	row := q.db.QueryRowContext(ctx, getProfileByHandle, handle)
+	var i GetProfileByHandleRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.IsChirpyRed,
+		&i.FollowerCount,
+		&i.FollowingCount,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at 
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at 
 FROM refresh_tokens
-WHERE token = $1
+WHERE token_hash = $1
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
 FROM users
 WHERE email = $1
 `
@@ -227,12 +295,20 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
 FROM users
 WHERE id = $1
 `
@@ -247,6 +323,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -265,22 +349,185 @@ UPDAte refresh_tokens
 SET
     revoked_at = NOW(),
     updated_at = NOW()
-WHERE token = $1
+WHERE token_hash = $1
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, tokenHash)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1
+    AND revoked_at IS NULL
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
 	return err
 }
 
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW(),
+    updated_at = NOW()
+WHERE token_hash = $1
+    AND rotated_at IS NULL
+    AND revoked_at IS NULL
+`
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT u.id,
+    u.handle,
+    u.display_name,
+    u.is_chirpy_red,
+    (LOWER(u.handle) = $1::text)::bool AS exact_match,
+    EXISTS (
+        SELECT 1
+        FROM follows f
+        WHERE f.follower_id = $2::uuid
+            AND f.followee_id = u.id
+    ) AS followed_by_me
+FROM users u
+WHERE (
+        LOWER(u.handle) LIKE $3::text
+        OR LOWER(u.display_name) LIKE $3::text
+        OR LOWER(u.handle) % $1::text
+        OR $1::text <% LOWER(u.display_name)
+    )
+    AND (
+        u.id = $2::uuid
+        OR u.shadow_banned_at IS NULL
+    )
+ORDER BY exact_match DESC,
+    followed_by_me DESC,
+    GREATEST(
+        similarity(LOWER(u.handle), $1::text),
+        word_similarity($1::text, LOWER(u.display_name))
+    ) DESC,
+    LOWER(u.handle)
+LIMIT $4
+`
+
+type SearchUsersParams struct {
+	Query         string
+	ViewerID      uuid.NullUUID
+	PrefixPattern string
+	ResultLimit   int32
+}
+
+type SearchUsersRow struct {
+	ID           uuid.UUID
+	Handle       string
+	DisplayName  string
+	IsChirpyRed  bool
+	ExactMatch   bool
+	FollowedByMe bool
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers, arg.Query, arg.ViewerID, arg.PrefixPattern, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.IsChirpyRed,
+			&i.ExactMatch,
+			&i.FollowedByMe,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserRoleByEmail = `-- name: SetUserRoleByEmail :one
+UPDATE users
+SET role = $1,
+    updated_at = NOW()
+WHERE email = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
+`
+
+type SetUserRoleByEmailParams struct {
+	Role  string
+	Email string
+}
+
+func (q *Queries) SetUserRoleByEmail(ctx context.Context, arg SetUserRoleByEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRoleByEmail, arg.Role, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
     updated_at = NOW(),
     email = $1,
-    hashed_password = $2
+    hashed_password = $2,
+    email_verified_at = CASE
+        WHEN email = $1 THEN email_verified_at
+    END
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
 `
 
 type UpdateUserParams struct {
@@ -299,6 +546,62 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    updated_at = NOW(),
+    handle = $1,
+    display_name = $2,
+    bio = $3,
+    location = $4
+WHERE id = $5
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
+`
+
+type UpdateUserProfileParams struct {
+	Handle      string
+	DisplayName string
+	Bio         string
+	Location    string
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -309,7 +612,7 @@ SET
     is_chirpy_red = true,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, suspended_at, shadow_banned_at, role, email_verified_at
 `
 
 func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -322,6 +625,14 @@ func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.SuspendedAt,
+		&i.ShadowBannedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,117 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Entity is a hashtag or mention found in a chirp body. Offsets count runes,
+// not bytes, so clients can slice the body however their platform indexes
+// strings after converting.
+type Entity struct {
+	// Text is the entity as written, without the leading # or @.
+	Text string
+	// Start is the offset of the # or @.
+	Start int
+	// End is the offset just past the last rune of the entity.
+	End int
+}
+
+// Hashtags returns every #hashtag in s in order of appearance. A tag is a run
+// of letters, marks, digits and underscores in any script, and has to contain
+// at least one letter so that things like "#1" aren't picked up.
+func Hashtags(s string) []Entity {
+	return scan(s, '#', isHashtagRune, func(tag []rune) bool {
+		for _, r := range tag {
+			if unicode.IsLetter(r) || unicode.IsMark(r) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NormalizeHashtag returns the form a tag is indexed under, so #Go and #GO
+// land on the same hashtag.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(tag)
+}
+
+// MaxHandleLength is the longest handle a user can register, and so the
+// longest @mention worth resolving.
+const MaxHandleLength = 15
+
+// Mentions returns every @handle in s in order of appearance. Handles are
+// ASCII letters, digits and underscores, so an email address like
+// "me@example.com" is not a mention.
+func Mentions(s string) []Entity {
+	return scan(s, '@', isHandleRune, func(handle []rune) bool {
+		return len(handle) <= MaxHandleLength
+	})
+}
+
+// ValidHandle reports whether h could be registered as a handle.
+func ValidHandle(h string) bool {
+	if len(h) == 0 || len(h) > MaxHandleLength {
+		return false
+	}
+	for _, r := range h {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeHandle returns the form handles are compared in. Handles keep the
+// casing they were registered with but are unique regardless of case.
+func NormalizeHandle(h string) string {
+	return strings.ToLower(h)
+}
+
+func isHandleRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// scan finds every sigil-prefixed run of body runes. The sigil only counts at
+// the start of the text or after a rune that couldn't be part of the entity,
+// so "a#b" and "##x" don't produce matches in the middle of a word.
+func scan(s string, sigil rune, isBody func(rune) bool, valid func([]rune) bool) []Entity {
+	runes := []rune(s)
+	var found []Entity
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != sigil {
+			continue
+		}
+		if i > 0 && (isBody(runes[i-1]) || runes[i-1] == sigil) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isBody(runes[end]) {
+			end++
+		}
+
+		// a run cut short by a letter the entity can't contain, as in "@zoë",
+		// is not an entity at all rather than a truncated one
+		if end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsMark(runes[end]) || unicode.IsDigit(runes[end])) {
+			i = end - 1
+			continue
+		}
+
+		text := runes[i+1 : end]
+		if len(text) == 0 || !valid(text) {
+			continue
+		}
+
+		found = append(found, Entity{Text: string(text), Start: i, End: end})
+		i = end - 1
+	}
+
+	return found
+}
@@ -0,0 +1,119 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "single tag",
+			body: "learning #golang today",
+			want: []Entity{{Text: "golang", Start: 9, End: 16}},
+		},
+		{
+			name: "punctuation ends a tag",
+			body: "#go, #sql!",
+			want: []Entity{{Text: "go", Start: 0, End: 3}, {Text: "sql", Start: 5, End: 9}},
+		},
+		{
+			name: "unicode letters",
+			body: "hello #café and #東京",
+			want: []Entity{{Text: "café", Start: 6, End: 11}, {Text: "東京", Start: 16, End: 19}},
+		},
+		{
+			name: "digits only is not a tag",
+			body: "we're #1",
+			want: nil,
+		},
+		{
+			name: "no tag in the middle of a word",
+			body: "a#b ##c",
+			want: nil,
+		},
+		{
+			name: "underscores and digits are allowed",
+			body: "#go_1_23",
+			want: []Entity{{Text: "go_1_23", Start: 0, End: 8}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Hashtags(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if got := NormalizeHashtag("GoLang"); got != "golang" {
+		t.Fatalf("NormalizeHashtag returned %q, want %q", got, "golang")
+	}
+	if got := NormalizeHashtag("CAFÉ"); got != "café" {
+		t.Fatalf("NormalizeHashtag returned %q, want %q", got, "café")
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "mentions in a sentence",
+			body: "thanks @alice and @Bob_2!",
+			want: []Entity{{Text: "alice", Start: 7, End: 13}, {Text: "Bob_2", Start: 18, End: 24}},
+		},
+		{
+			name: "email addresses are not mentions",
+			body: "mail me@example.com",
+			want: nil,
+		},
+		{
+			name: "offsets count runes",
+			body: "héllo @zoe",
+			want: []Entity{{Text: "zoe", Start: 6, End: 10}},
+		},
+		{
+			name: "handle cut short by a non-ASCII letter",
+			body: "hi @zoë",
+			want: nil,
+		},
+		{
+			name: "too long to be a handle",
+			body: "@abcdefghijklmnop",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Mentions(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	for _, h := range []string{"a", "alice", "Bob_2", "abcdefghijklmno"} {
+		if !ValidHandle(h) {
+			t.Errorf("ValidHandle(%q) = false, want true", h)
+		}
+	}
+	for _, h := range []string{"", "abcdefghijklmnop", "bad-handle", "zoë", "with space"} {
+		if ValidHandle(h) {
+			t.Errorf("ValidHandle(%q) = true, want false", h)
+		}
+	}
+}
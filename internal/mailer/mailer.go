@@ -0,0 +1,188 @@
+// Package mailer sends the emails chirpy needs to reach people outside the
+// API, such as password reset codes.
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var errHeaderNewline = errors.New("email headers can't contain line breaks")
+
+// SMTP sends mail through an SMTP server. It authenticates with PLAIN when
+// Username is set, which net/smtp only allows over TLS or to localhost.
+type SMTP struct {
+	// Addr is the server's host:port.
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Send delivers msg. The connection is dialled with ctx and closed if ctx
+// ends, and ctx's deadline, if any, applies to the whole conversation with
+// the server, so a stalled server can't hold a send open past it.
+func (m SMTP) Send(ctx context.Context, msg Message) error {
+	raw, err := format(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", m.Addr, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return fmt.Errorf("couldn't connect to %s: %w", m.Addr, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := m.deliver(conn, host, msg.To, raw); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return fmt.Errorf("couldn't send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// deliver does what smtp.SendMail does over a connection that is already
+// open: STARTTLS when the server offers it, then AUTH, then the message.
+func (m SMTP) deliver(conn net.Conn, host, to string, raw []byte) error {
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Dir writes each message to its own .eml file in a directory instead of
+// sending it, for development and tests.
+type Dir string
+
+func (d Dir) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	raw, err := format("chirpy@localhost", msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), safeName(msg.To))
+	if err := os.WriteFile(filepath.Join(string(d), name), raw, 0o600); err != nil {
+		return fmt.Errorf("couldn't save mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// Log prints each message instead of sending it. A nil Logger means the
+// standard logger.
+type Log struct {
+	Logger *log.Logger
+}
+
+func (l Log) Send(ctx context.Context, msg Message) error {
+	logf := log.Printf
+	if l.Logger != nil {
+		logf = l.Logger.Printf
+	}
+	logf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// format renders msg as an RFC 5322 message with CRLF line endings.
+func format(from string, msg Message, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderNewline
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
+
+// safeName keeps the letters, digits and a few symbols of an address so it
+// can go in a file name.
+func safeName(address string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, address)
+}
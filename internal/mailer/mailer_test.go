@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	got, err := format("chirpy@example.com", Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	}, date)
+	if err != nil {
+		t.Fatalf("format returned an error: %s", err)
+	}
+
+	want := "From: chirpy@example.com\r\n" +
+		"To: user@example.com\r\n" +
+		"Subject: Reset your password\r\n" +
+		"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"line one\r\nline two"
+	if string(got) != want {
+		t.Fatalf("format returned %q, want %q", got, want)
+	}
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	for _, msg := range []Message{
+		{To: "user@example.com\r\nBcc: everyone@example.com", Subject: "hi"},
+		{To: "user@example.com", Subject: "hi\nBcc: everyone@example.com"},
+	} {
+		if _, err := format("chirpy@example.com", msg, time.Now()); err == nil {
+			t.Errorf("format accepted %+v", msg)
+		}
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "the code is 1234"}
+	if err := Dir(dir).Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send returned an error: %s", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-user@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one message file, found %v (%v)", files, err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("couldn't read message: %s", err)
+	}
+	if !strings.Contains(string(raw), "Subject: Hello\r\n") || !strings.HasSuffix(string(raw), "the code is 1234") {
+		t.Fatalf("message file has unexpected contents: %q", raw)
+	}
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := Log{Logger: log.New(&buf, "", 0)}
+	if err := l.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "the code is 1234"}); err != nil {
+		t.Fatalf("Send returned an error: %s", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "user@example.com") || !strings.Contains(got, "the code is 1234") {
+		t.Fatalf("Log wrote %q", got)
+	}
+}
+
+func TestSMTPGivesUpAtDeadline(t *testing.T) {
+	// a server that accepts connections and never says anything
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	m := SMTP{Addr: ln.Addr().String(), From: "chirpy@example.com"}
+	err = m.Send(ctx, Message{To: "user@example.com", Subject: "Hello", Body: "hi"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send returned %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Send took %s to give up", elapsed)
+	}
+}
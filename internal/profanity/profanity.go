@@ -0,0 +1,366 @@
+package profanity
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Mask is what every masked word is replaced with, whatever its length.
+const Mask = "****"
+
+// MaxTermLength is the longest word a list can contain.
+const MaxTermLength = 50
+
+// Action is what happens to text containing a term.
+type Action string
+
+const (
+	// ActionMask replaces the word with Mask.
+	ActionMask Action = "mask"
+	// ActionReject refuses the text altogether.
+	ActionReject Action = "reject"
+	// ActionFlag lets the text through but marks it for review.
+	ActionFlag Action = "flag"
+)
+
+// ParseAction checks s is one of the known actions.
+func ParseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case ActionMask, ActionReject, ActionFlag:
+		return a, nil
+	}
+	return "", fmt.Errorf("action must be %q, %q or %q", ActionMask, ActionReject, ActionFlag)
+}
+
+// Term is an entry in a word list.
+type Term struct {
+	Word   string
+	Action Action
+}
+
+// ValidWord reports whether w can be matched at all: a single word, with at
+// least one letter or digit, of no more than MaxTermLength characters.
+func ValidWord(w string) bool {
+	if w == "" || len([]rune(w)) > MaxTermLength {
+		return false
+	}
+	hasLetter := false
+	for _, r := range w {
+		if !isWordRune(r) {
+			return false
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+// Source supplies the terms to filter. It is read again on every Reload.
+type Source interface {
+	Terms(ctx context.Context) ([]Term, error)
+}
+
+// SourceFunc adapts a function, such as a database query, to a Source.
+type SourceFunc func(ctx context.Context) ([]Term, error)
+
+func (f SourceFunc) Terms(ctx context.Context) ([]Term, error) {
+	return f(ctx)
+}
+
+// FileSource reads one term per line from a file: the word, optionally
+// followed by its action, which defaults to mask. Blank lines and lines
+// starting with # are skipped.
+type FileSource string
+
+func (path FileSource) Terms(ctx context.Context) ([]Term, error) {
+	file, err := os.Open(string(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var terms []Term
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		term := Term{Word: fields[0], Action: ActionMask}
+		switch len(fields) {
+		case 1:
+		case 2:
+			if term.Action, err = ParseAction(fields[1]); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: expected a word and an optional action", path, line)
+		}
+		terms = append(terms, term)
+	}
+	return terms, scanner.Err()
+}
+
+// Match is a term found in some text. Start and End are byte offsets into
+// that text.
+type Match struct {
+	Term
+	Start int
+	End   int
+}
+
+// Verdict is what the filter made of some text.
+type Verdict struct {
+	// Text has every masked word replaced with Mask.
+	Text string
+	// Rejected and Flagged list the words, as they appear in the word list,
+	// that call for those actions.
+	Rejected []string
+	Flagged  []string
+}
+
+// Filter finds words from its Source in text. It is safe for concurrent use,
+// including while it is being reloaded.
+type Filter struct {
+	source Source
+	terms  atomic.Pointer[map[string]Term]
+}
+
+// New returns a filter that matches nothing until Reload is called.
+func New(source Source) *Filter {
+	f := &Filter{source: source}
+	f.terms.Store(&map[string]Term{})
+	return f
+}
+
+// Reload reads the word list from the source again. If that fails the
+// previous list stays in use. When two words fold to the same form, the
+// strictest action wins.
+func (f *Filter) Reload(ctx context.Context) error {
+	list, err := f.source.Terms(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't load word list: %w", err)
+	}
+
+	terms := make(map[string]Term, len(list))
+	for _, t := range list {
+		key := fold(t.Word)
+		if key == "" {
+			continue
+		}
+		if prev, ok := terms[key]; ok && severity[prev.Action] >= severity[t.Action] {
+			continue
+		}
+		terms[key] = t
+	}
+
+	f.terms.Store(&terms)
+	return nil
+}
+
+var severity = map[Action]int{
+	ActionFlag:   1,
+	ActionMask:   2,
+	ActionReject: 3,
+}
+
+// Find returns every filtered word in s, in order. Words only match whole,
+// ignoring case, and catch the usual ways of disguising them: digits and
+// symbols standing in for letters, and letters repeated for emphasis.
+func (f *Filter) Find(s string) []Match {
+	terms := *f.terms.Load()
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var found []Match
+	for _, tok := range tokens(s) {
+		if term, ok := lookup(terms, s[tok.Start:tok.End]); ok {
+			found = append(found, Match{Term: term, Start: tok.Start, End: tok.End})
+			continue
+		}
+
+		// symbols only count as letters inside a word, so "fornax!" is
+		// still checked as "fornax"
+		trimmed := trimSymbols(s, tok)
+		if trimmed == tok || trimmed.Start == trimmed.End {
+			continue
+		}
+		if term, ok := lookup(terms, s[trimmed.Start:trimmed.End]); ok {
+			found = append(found, Match{Term: term, Start: trimmed.Start, End: trimmed.End})
+		}
+	}
+	return found
+}
+
+// Check applies the action of every term found in s. Masked words are
+// replaced and the rest of s is left untouched. Each rejected or flagged word
+// is listed once.
+func (f *Filter) Check(s string) Verdict {
+	var (
+		verdict Verdict
+		masked  []Match
+		seen    = make(map[string]bool)
+	)
+
+	for _, m := range f.Find(s) {
+		switch m.Action {
+		case ActionMask:
+			masked = append(masked, m)
+		case ActionReject:
+			if !seen[m.Word] {
+				verdict.Rejected = append(verdict.Rejected, m.Word)
+			}
+		case ActionFlag:
+			if !seen[m.Word] {
+				verdict.Flagged = append(verdict.Flagged, m.Word)
+			}
+		}
+		seen[m.Word] = true
+	}
+
+	verdict.Text = MaskMatches(s, masked)
+	return verdict
+}
+
+// MaskMatches replaces each of matches in s with Mask. matches must be in
+// order, as Find returns them.
+func MaskMatches(s string, matches []Match) string {
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m.Start])
+		b.WriteString(Mask)
+		last = m.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// leet maps the symbols and digits commonly swapped in for letters.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// fold brings a word to the form words are compared in: lower case, with
+// leetspeak undone, so "F0RNAX" and "fornax" compare equal.
+func fold(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		r = unicode.ToLower(r)
+		if mapped, ok := leet[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// collapse shortens every run of three or more of the same letter to keep
+// letters. Doubled letters are left alone, since plenty of ordinary words
+// have them.
+func collapse(word string, keep int) string {
+	runes := []rune(word)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		n := j - i
+		if n >= 3 {
+			n = keep
+		}
+		b.WriteString(strings.Repeat(string(runes[i]), n))
+		i = j
+	}
+	return b.String()
+}
+
+// lookup finds the term word stands for. Letters repeated for emphasis, as
+// in "fornaaax", are tried both as one letter and as two, so "fooornax" and
+// "kerfffuffle" match without "but" being read as "butt".
+func lookup(terms map[string]Term, word string) (Term, bool) {
+	folded := fold(word)
+	if term, ok := terms[folded]; ok {
+		return term, true
+	}
+	for _, keep := range []int{1, 2} {
+		if c := collapse(folded, keep); c != folded {
+			if term, ok := terms[c]; ok {
+				return term, true
+			}
+		}
+	}
+	return Term{}, false
+}
+
+func isSymbol(r rune) bool {
+	_, ok := leet[r]
+	return ok && !unicode.IsDigit(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || isSymbol(r)
+}
+
+type span struct {
+	Start int
+	End   int
+}
+
+// tokens splits s into runs of word runes, as byte offsets.
+func tokens(s string) []span {
+	var found []span
+	start := -1
+	for i, r := range s {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			found = append(found, span{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		found = append(found, span{Start: start, End: len(s)})
+	}
+	return found
+}
+
+// trimSymbols drops the symbols at either end of tok. All of them are ASCII,
+// so each is one byte.
+func trimSymbols(s string, tok span) span {
+	for tok.Start < tok.End && isSymbol(rune(s[tok.Start])) {
+		tok.Start++
+	}
+	for tok.End > tok.Start && isSymbol(rune(s[tok.End-1])) {
+		tok.End--
+	}
+	return tok
+}
@@ -0,0 +1,250 @@
+package profanity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newFilter(t *testing.T, terms ...Term) *Filter {
+	t.Helper()
+	f := New(SourceFunc(func(ctx context.Context) ([]Term, error) {
+		return terms, nil
+	}))
+	if err := f.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload returned an error: %s", err)
+	}
+	return f
+}
+
+func masked(words ...string) []Term {
+	terms := make([]Term, len(words))
+	for i, w := range words {
+		terms[i] = Term{Word: w, Action: ActionMask}
+	}
+	return terms
+}
+
+func TestCheckMasks(t *testing.T) {
+	f := newFilter(t, masked("kerfuffle", "sharbert", "fornax")...)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "clean text keeps its casing",
+			body: "This Is Fine",
+			want: "This Is Fine",
+		},
+		{
+			name: "any casing",
+			body: "What a Kerfuffle today",
+			want: "What a **** today",
+		},
+		{
+			name: "punctuation around a word",
+			body: "Sharbert! (fornax), 'kerfuffle'",
+			want: "****! (****), '****'",
+		},
+		{
+			name: "every occurrence",
+			body: "fornax fornax FORNAX",
+			want: "**** **** ****",
+		},
+		{
+			name: "leetspeak",
+			body: "k3rfuffl3 $h4rb3rt f0rn@x",
+			want: "**** **** ****",
+		},
+		{
+			name: "repeated letters",
+			body: "fooornaaaxxx and sharrrbert",
+			want: "**** and ****",
+		},
+		{
+			name: "letters repeated on a doubled letter",
+			body: "kerffffuffle",
+			want: "****",
+		},
+		{
+			name: "only whole words",
+			body: "fornaxes unsharbert",
+			want: "fornaxes unsharbert",
+		},
+		{
+			name: "unicode text around a word",
+			body: "Ça alors, fornax! 東京",
+			want: "Ça alors, ****! 東京",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.Check(tt.body).Text
+			if got != tt.want {
+				t.Fatalf("Check(%q).Text = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckKeepsShorterWords(t *testing.T) {
+	f := newFilter(t, masked("butt", "ass", "boob")...)
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"but I said so", "but I said so"},
+		{"as I said", "as I said"},
+		{"Bob is here", "Bob is here"},
+		{"buttt, asss and booooob", "****, **** and ****"},
+	}
+
+	for _, tt := range tests {
+		if got := f.Check(tt.body).Text; got != tt.want {
+			t.Errorf("Check(%q).Text = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestCheckActions(t *testing.T) {
+	f := newFilter(t,
+		Term{Word: "fornax", Action: ActionMask},
+		Term{Word: "sharbert", Action: ActionReject},
+		Term{Word: "kerfuffle", Action: ActionFlag},
+	)
+
+	got := f.Check("fornax, kerfuffle and sh4rbert, and another kerfuffle")
+	want := Verdict{
+		Text:     "****, kerfuffle and sh4rbert, and another kerfuffle",
+		Rejected: []string{"sharbert"},
+		Flagged:  []string{"kerfuffle"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Check returned %+v, want %+v", got, want)
+	}
+}
+
+func TestStrictestActionWins(t *testing.T) {
+	f := newFilter(t,
+		Term{Word: "fornax", Action: ActionReject},
+		Term{Word: "FORNAX", Action: ActionFlag},
+		Term{Word: "f0rnax", Action: ActionMask},
+	)
+
+	if got := f.Check("fornax"); len(got.Rejected) != 1 {
+		t.Fatalf("Check returned %+v, want fornax rejected", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	f := newFilter(t, masked("Fornax")...)
+
+	got := f.Find("oh f0rnax!")
+	want := []Match{{Term: Term{Word: "Fornax", Action: ActionMask}, Start: 3, End: 9}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Find returned %+v, want %+v", got, want)
+	}
+}
+
+func TestReload(t *testing.T) {
+	terms := masked("fornax")
+	var loadErr error
+	f := New(SourceFunc(func(ctx context.Context) ([]Term, error) {
+		return terms, loadErr
+	}))
+
+	if got := f.Check("fornax").Text; got != "fornax" {
+		t.Fatalf("filter matched %q before it was loaded", got)
+	}
+
+	if err := f.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload returned an error: %s", err)
+	}
+	if got := f.Check("fornax sharbert").Text; got != "**** sharbert" {
+		t.Fatalf("Check returned %q after the first load", got)
+	}
+
+	terms = masked("sharbert")
+	if err := f.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload returned an error: %s", err)
+	}
+	if got := f.Check("fornax sharbert").Text; got != "fornax ****" {
+		t.Fatalf("Check returned %q after reloading", got)
+	}
+
+	loadErr = errors.New("database is down")
+	if err := f.Reload(context.Background()); err == nil {
+		t.Fatalf("Reload didn't return the source's error")
+	}
+	if got := f.Check("fornax sharbert").Text; got != "fornax ****" {
+		t.Fatalf("a failed reload changed the word list: Check returned %q", got)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# filtered words\nfornax\n\n  sharbert   reject\nkerfuffle flag\n"), 0o600); err != nil {
+		t.Fatalf("couldn't write word list: %s", err)
+	}
+
+	got, err := FileSource(path).Terms(context.Background())
+	if err != nil {
+		t.Fatalf("Terms returned an error: %s", err)
+	}
+	want := []Term{
+		{Word: "fornax", Action: ActionMask},
+		{Word: "sharbert", Action: ActionReject},
+		{Word: "kerfuffle", Action: ActionFlag},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Terms returned %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("fornax\nsharbert ban\n"), 0o600); err != nil {
+		t.Fatalf("couldn't write word list: %s", err)
+	}
+	if _, err := FileSource(path).Terms(context.Background()); err == nil {
+		t.Fatalf("Terms accepted an unknown action")
+	}
+}
+
+func TestValidWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"fornax", true},
+		{"f0rn@x", true},
+		{"café", true},
+		{"", false},
+		{"two words", false},
+		{"@$!", false},
+		{"a-b", false},
+		{strings.Repeat("a", MaxTermLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidWord(tt.word); got != tt.want {
+			t.Errorf("ValidWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for _, a := range []Action{ActionMask, ActionReject, ActionFlag} {
+		if got, err := ParseAction(string(a)); err != nil || got != a {
+			t.Errorf("ParseAction(%q) = %q, %v", a, got, err)
+		}
+	}
+	if _, err := ParseAction("ban"); err == nil {
+		t.Errorf("ParseAction accepted an unknown action")
+	}
+}
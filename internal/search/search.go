@@ -0,0 +1,164 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxTerms bounds how many terms a query can have, so one search can't build
+// an arbitrarily expensive tsquery.
+const MaxTerms = 16
+
+var (
+	ErrEmptyQuery   = errors.New("query has nothing to search for")
+	ErrTooManyTerms = fmt.Errorf("query can have at most %d terms", MaxTerms)
+)
+
+// StartSel and StopSel wrap each match in the snippets Postgres builds. They
+// are control characters so they can't be confused with anything in a chirp,
+// and have to agree with chr(2) and chr(3) in the search query.
+const (
+	StartSel = "\x02"
+	StopSel  = "\x03"
+)
+
+type term struct {
+	words   []string
+	prefix  bool
+	exclude bool
+}
+
+// ToTSQuery turns a search box query into the text of a tsquery. It
+// understands:
+//
+//	word        chirps containing the word, or another form of it
+//	word*       chirps with a word starting with word
+//	"two words" chirps with the words next to each other, in order
+//	-word       chirps without the word; works on phrases and prefixes too
+//
+// Every other term has to match. Punctuation is treated like a space, so the
+// result is always safe to hand to to_tsquery.
+func ToTSQuery(q string) (string, error) {
+	terms := parse(q)
+	if len(terms) > MaxTerms {
+		return "", ErrTooManyTerms
+	}
+
+	parts := make([]string, 0, len(terms))
+	included := false
+	for _, t := range terms {
+		if !t.exclude {
+			included = true
+		}
+		parts = append(parts, t.String())
+	}
+
+	// a query made only of exclusions would have to scan every chirp
+	if !included {
+		return "", ErrEmptyQuery
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+func (t term) String() string {
+	lexemes := make([]string, len(t.words))
+	for i, w := range t.words {
+		// words are letters and digits only, so they never need escaping
+		lexemes[i] = "'" + w + "'"
+	}
+	if t.prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+
+	s := strings.Join(lexemes, " <-> ")
+	if len(lexemes) > 1 {
+		s = "(" + s + ")"
+	}
+	if t.exclude {
+		s = "!" + s
+	}
+	return s
+}
+
+// parse splits q into terms. It never fails: a quote that is never closed
+// runs to the end of the query, which is almost always what was meant.
+func parse(q string) []term {
+	runes := []rune(q)
+	var terms []term
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var t term
+		if runes[i] == '-' {
+			t.exclude = true
+			i++
+		}
+
+		var text []rune
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = runes[i+1 : end]
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			text = runes[i:end]
+			i = end
+		}
+
+		t.prefix = strings.HasSuffix(string(text), "*")
+		t.words = strings.FieldsFunc(string(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+		})
+		if len(t.words) == 0 {
+			continue
+		}
+		terms = append(terms, t)
+	}
+
+	return terms
+}
+
+// Span is a highlighted part of a snippet. Like entity offsets, they count
+// runes rather than bytes.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Highlights strips the StartSel and StopSel markers from a snippet and
+// returns the plain text along with where the markers were.
+func Highlights(marked string) (string, []Span) {
+	var (
+		plain []rune
+		spans []Span
+		start = -1
+	)
+
+	for _, r := range marked {
+		switch string(r) {
+		case StartSel:
+			start = len(plain)
+		case StopSel:
+			if start >= 0 && start < len(plain) {
+				spans = append(spans, Span{Start: start, End: len(plain)})
+			}
+			start = -1
+		default:
+			plain = append(plain, r)
+		}
+	}
+
+	return string(plain), spans
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "single word",
+			query: "golang",
+			want:  "'golang'",
+		},
+		{
+			name:  "every word has to match",
+			query: "learning  golang",
+			want:  "'learning' & 'golang'",
+		},
+		{
+			name:  "prefix",
+			query: "gola*",
+			want:  "'gola':*",
+		},
+		{
+			name:  "phrase",
+			query: `"hello world"`,
+			want:  "('hello' <-> 'world')",
+		},
+		{
+			name:  "phrase ending in a prefix",
+			query: `"hello wor*"`,
+			want:  "('hello' <-> 'wor':*)",
+		},
+		{
+			name:  "excluded word and phrase",
+			query: `go -rust -"java script"`,
+			want:  "'go' & !'rust' & !('java' <-> 'script')",
+		},
+		{
+			name:  "unclosed quote runs to the end",
+			query: `"hello world`,
+			want:  "('hello' <-> 'world')",
+		},
+		{
+			name:  "punctuation splits words",
+			query: "e-mail",
+			want:  "('e' <-> 'mail')",
+		},
+		{
+			name:  "tsquery syntax is not passed through",
+			query: "a'|b & !c:*",
+			want:  "('a' <-> 'b') & 'c':*",
+		},
+		{
+			name:  "unicode words",
+			query: "café 東京",
+			want:  "'café' & '東京'",
+		},
+		{
+			name:    "empty",
+			query:   "  ",
+			wantErr: ErrEmptyQuery,
+		},
+		{
+			name:    "only punctuation",
+			query:   `"" - ***`,
+			wantErr: ErrEmptyQuery,
+		},
+		{
+			name:    "only exclusions",
+			query:   "-rust -java",
+			wantErr: ErrEmptyQuery,
+		},
+		{
+			name:    "too many terms",
+			query:   "a b c d e f g h i j k l m n o p q",
+			wantErr: ErrTooManyTerms,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToTSQuery(tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ToTSQuery(%q) error = %v, want %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ToTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	tests := []struct {
+		name      string
+		marked    string
+		wantPlain string
+		wantSpans []Span
+	}{
+		{
+			name:      "no matches",
+			marked:    "nothing here",
+			wantPlain: "nothing here",
+			wantSpans: nil,
+		},
+		{
+			name:      "two matches",
+			marked:    "learning \x02golang\x03 and \x02go\x03",
+			wantPlain: "learning golang and go",
+			wantSpans: []Span{{Start: 9, End: 15}, {Start: 20, End: 22}},
+		},
+		{
+			name:      "offsets count runes",
+			marked:    "café \x02東京\x03",
+			wantPlain: "café 東京",
+			wantSpans: []Span{{Start: 5, End: 7}},
+		},
+		{
+			name:      "unbalanced markers are dropped",
+			marked:    "\x03a \x02\x03b \x02c",
+			wantPlain: "a b c",
+			wantSpans: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, spans := Highlights(tt.marked)
+			if plain != tt.wantPlain {
+				t.Fatalf("Highlights(%q) text = %q, want %q", tt.marked, plain, tt.wantPlain)
+			}
+			if !reflect.DeepEqual(spans, tt.wantSpans) {
+				t.Fatalf("Highlights(%q) spans = %+v, want %+v", tt.marked, spans, tt.wantSpans)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+const (
+	// defaultKeyRotation is how often a new signing key is made, unless
+	// JWT_KEY_ROTATION says otherwise. A key signs for that long and then
+	// verifies for as long again, well past the last token it signed expiring.
+	defaultKeyRotation = 30 * 24 * time.Hour
+	minKeyRotation     = 24 * time.Hour
+	// keyPublishDelay is how long a new key is published before it signs
+	// anything, so every server has loaded it, and the JWKS everyone caches
+	// lists it, by the time tokens signed with it turn up
+	keyPublishDelay = 5 * time.Minute
+	// keyReloadInterval is how often the keyring is read back from the
+	// database. It must be shorter than keyPublishDelay.
+	keyReloadInterval = time.Minute
+	// tokenLeeway allows for the clocks of the servers signing and checking
+	// a token disagreeing a little
+	tokenLeeway = 30 * time.Second
+	// authRealm names the protection space in WWW-Authenticate challenges
+	authRealm = "chirpy"
+)
+
+// keyRotationFromEnv reads JWT_KEY_ROTATION, a duration such as "720h".
+func keyRotationFromEnv() (time.Duration, error) {
+	raw := os.Getenv("JWT_KEY_ROTATION")
+	if raw == "" {
+		return defaultKeyRotation, nil
+	}
+
+	rotation, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("JWT_KEY_ROTATION: %s", err)
+	}
+	if rotation < minKeyRotation {
+		return 0, fmt.Errorf("JWT_KEY_ROTATION must be at least %s", minKeyRotation)
+	}
+	return rotation, nil
+}
+
+// keySealerFromEnv reads JWT_KEY_ENCRYPTION_KEY, the base64 key signing keys
+// are encrypted with in the database. It has to be the same on every server.
+func keySealerFromEnv() (*auth.KeySealer, error) {
+	raw := os.Getenv("JWT_KEY_ENCRYPTION_KEY")
+	if raw == "" {
+		return nil, errors.New("JWT_KEY_ENCRYPTION_KEY must be set")
+	}
+
+	key, err := auth.ParseKeyEncryptionKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY: %s", err)
+	}
+	return auth.NewKeySealer(key)
+}
+
+// rotateKeys makes a new signing key when the newest is due for rotation,
+// drops keys nothing they signed can still be valid for, and loads what is
+// left into the keyring. Every server runs it; if two make a key at once,
+// both keys are used, which is harmless.
+func (cfg *apiConfig) rotateKeys(ctx context.Context) error {
+	// signing_keys.created_at has no time zone, so keep it in UTC
+	now := time.Now().UTC()
+
+	if err := cfg.dbQueries.DeleteSigningKeysBefore(ctx, now.Add(-2*cfg.keyRotation)); err != nil {
+		return fmt.Errorf("couldn't delete retired signing keys: %s", err)
+	}
+
+	rows, err := cfg.dbQueries.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't list signing keys: %s", err)
+	}
+
+	if len(rows) == 0 || rows[len(rows)-1].CreatedAt.Before(now.Add(-cfg.keyRotation)) {
+		key, err := auth.GenerateSigningKey(now)
+		if err != nil {
+			return fmt.Errorf("couldn't generate signing key: %s", err)
+		}
+		sealed, err := cfg.keySealer.Seal(key)
+		if err != nil {
+			return fmt.Errorf("couldn't encrypt signing key: %s", err)
+		}
+		row := database.CreateSigningKeyParams{
+			ID:                  key.ID,
+			EncryptedPrivateKey: sealed,
+			CreatedAt:           key.CreatedAt,
+		}
+		if err := cfg.dbQueries.CreateSigningKey(ctx, row); err != nil {
+			return fmt.Errorf("couldn't save signing key: %s", err)
+		}
+		log.Printf("created signing key %s", key.ID)
+		rows = append(rows, database.SigningKey(row))
+	}
+
+	keys := make([]auth.SigningKey, 0, len(rows))
+	for _, v := range rows {
+		key, err := cfg.keySealer.Open(v.ID, v.EncryptedPrivateKey, v.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %s", v.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	cfg.keys.Load(keys, now, keyPublishDelay)
+	return nil
+}
+
+// runKeyRotation keeps the keyring in step with the database every
+// keyReloadInterval until ctx is cancelled.
+func (cfg *apiConfig) runKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := cfg.rotateKeys(ctx); err != nil {
+			log.Printf("couldn't rotate signing keys: %s", err)
+		}
+	}
+}
+
+// getJWKS publishes the public keys access tokens are signed with, so other
+// services can check tokens without holding a secret.
+func (cfg *apiConfig) getJWKS(w http.ResponseWriter, r *http.Request) {
+	// a cached copy has to pick up a new key before it starts signing
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(keyPublishDelay.Seconds())))
+	respondWithJSON(w, http.StatusOK, cfg.keys.JWKS())
+}
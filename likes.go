@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpId,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	}); errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "chirp not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	// liking twice is a no-op thanks to ON CONFLICT DO NOTHING
+	if err := cfg.dbQueries.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpId,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't like chirp: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	if err := cfg.dbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpId,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't unlike chirp: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid user id"})
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	viewerID := cfg.optionalUserID(r)
+	listParams := database.ListLikedChirpsParams{
+		UserID:    userID,
+		ViewerID:  viewerID,
+		PageLimit: int32(limit + 1),
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorLikedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.ListLikedChirps(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	// likes are paged by when they happened, not when the chirp was posted
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.LikedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, v := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			Body:      v.Body,
+			UserID:    v.UserID,
+			InReplyTo: v.InReplyTo,
+			RechirpOf: v.RechirpOf,
+			QuoteOf:   v.QuoteOf,
+		})
+	}
+
+	resp, err := cfg.chirpsToReturn(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: resp, NextCursor: nextCursor})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nathnael-desta/chirpy/internal/mailer"
+)
+
+// mailTimeout bounds how long a background send can hold on to an SMTP
+// connection.
+const mailTimeout = 30 * time.Second
+
+// mailerFromEnv picks how mail is delivered from MAILER: "smtp", "file" or
+// "log", which is the default so development needs no setup.
+func mailerFromEnv() (mailer.Mailer, error) {
+	switch kind := os.Getenv("MAILER"); kind {
+	case "", "log":
+		return mailer.Log{}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("MAILER=file needs MAIL_DIR")
+		}
+		return mailer.Dir(dir), nil
+	case "smtp":
+		m := mailer.SMTP{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("MAIL_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+		if m.Addr == "" || m.From == "" {
+			return nil, fmt.Errorf("MAILER=smtp needs SMTP_ADDR and MAIL_FROM")
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", kind)
+	}
+}
+
+// sendMail delivers msg without holding up the request that caused it. That
+// also keeps response times from showing whether an email went out.
+func (cfg *apiConfig) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+
+		if err := cfg.mailer.Send(ctx, msg); err != nil {
+			log.Printf("couldn't send %q to %s: %s", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,14 +20,30 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/nathnael-desta/chirpy/internal/auth"
 	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/mailer"
+	"github.com/nathnael-desta/chirpy/internal/profanity"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
-	tokenSecret    string
 	polkaKey       string
+	trending       *trendingCache
+	profanity      *profanity.Filter
+	profanityFile  string
+	mailer         mailer.Mailer
+	baseURL        string
+	// keys signs access tokens and tokens checks them; keyRotation is how
+	// often a new signing key is made, and keySealer encrypts them at rest
+	keys        *auth.Keyring
+	tokens      *auth.Validator
+	keyRotation time.Duration
+	keySealer   *auth.KeySealer
+	// requireVerifiedEmail stops accounts posting chirps until they have
+	// confirmed their email address
+	requireVerifiedEmail bool
 }
 
 type User struct {
@@ -39,35 +58,71 @@ type errorReturn struct {
 }
 
 type returnVals struct {
-	Id        uuid.UUID     `json:"id"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	Body      string        `json:"body"`
-	UserID    uuid.NullUUID `json:"user_id"`
+	Id         uuid.UUID      `json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Body       string         `json:"body"`
+	UserID     uuid.NullUUID  `json:"user_id"`
+	InReplyTo  uuid.NullUUID  `json:"in_reply_to"`
+	RechirpOf  uuid.NullUUID  `json:"rechirp_of"`
+	QuoteOf    uuid.NullUUID  `json:"quote_of"`
+	Original   *originalChirp `json:"original,omitempty"`
+	ReplyCount int64          `json:"reply_count"`
+	LikeCount  int64          `json:"like_count"`
+	LikedByMe  *bool          `json:"liked_by_me,omitempty"`
+	Entities   chirpEntities  `json:"entities"`
+}
+
+// originalChirp is the chirp a rechirp or quote points at. When the original
+// has been deleted, or is hidden from the viewer, only the id is kept and
+// Deleted is set, so clients can render a tombstone.
+type originalChirp struct {
+	*returnVals
+	Id      uuid.UUID `json:"id"`
+	Deleted bool      `json:"deleted"`
+}
+
+type chirpsPage struct {
+	Chirps     []returnVals `json:"chirps"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 type userReturn struct {
-	Id           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Id            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	Handle        string    `json:"handle,omitempty"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	Location      string    `json:"location"`
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
 }
 
 type userParams struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Handle   string `json:"handle"`
+	// profile fields are pointers so an update can tell a field that was
+	// left out from one that is being cleared
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	Location    *string `json:"location"`
 }
 
 type CreateChirpParams struct {
-	Body   string `json:"body"`
-	UserID string `json:"user_id"`
+	Body      string `json:"body"`
+	InReplyTo string `json:"in_reply_to"`
+	QuoteOf   string `json:"quote_of"`
 }
 
 type RefreshTokenReturn struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type UpgradeToRedParams struct {
@@ -113,6 +168,13 @@ func respondWithError(w http.ResponseWriter, status int, err error) {
 	log.Printf("%s", err)
 }
 
+// respondWithAuthError turns away a request whose access token was missing,
+// no good, or not good enough, saying which in the WWW-Authenticate header.
+func respondWithAuthError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("WWW-Authenticate", auth.WWWAuthenticate(authRealm, err))
+	respondWithError(w, status, err)
+}
+
 func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 
 	params := userParams{}
@@ -132,6 +194,21 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// everyone needs a handle to be found and mentioned by
+	if params.Handle == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "handle is required"})
+		return
+	}
+
+	profile, status, err := cfg.profileUpdate(r.Context(), params, database.User{})
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		respondWithJSON(w, status, errorReturn{Error: err.Error()})
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 
 	if err != nil {
@@ -139,21 +216,48 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{Email: params.Email, HashedPassword: hashedPassword})
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	user, err := qtx.CreateUser(r.Context(), database.CreateUserParams{
+		Email:          params.Email,
+		HashedPassword: hashedPassword,
+		Handle:         profile.Handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		Location:       profile.Location,
+	})
 
 	if err != nil {
 		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "faild to query for create user"})
 		return
 	}
 
-	token, err := getToken(user.ID, cfg.tokenSecret)
+	verificationToken, err := issueEmailVerification(r.Context(), qtx, user.ID, user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit user: %s", err))
+		return
+	}
+	cfg.sendVerificationEmail(user.Email, verificationToken)
+
+	sessionID, refreshToken, err := cfg.startSession(r, user.ID)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	refreshToken, err := getRefreshToken(r.Context(), cfg, user.ID)
+	token, err := getToken(user, sessionID, cfg.keys)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
@@ -161,13 +265,19 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	returnVals := userReturn{
-		Id:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        token,
-		RefreshToken: refreshToken.Token,
-		IsChirpyRed:  user.IsChirpyRed,
+		Id:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Location:      user.Location,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   user.IsChirpyRed,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt.Valid,
 	}
 
 	respondWithJSON(w, http.StatusCreated, returnVals)
@@ -190,14 +300,14 @@ func (cfg *apiConfig) reset(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
+	viewer, ok := cfg.authenticatedUser(w, r)
+	if !ok {
 		return
 	}
+	viewerID := viewer.ID
 
-	if _, err := auth.ValidateJWT(token, cfg.tokenSecret); err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
+	if !cfg.canPost(viewer) {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errEmailUnverified.Error()})
 		return
 	}
 
@@ -208,78 +318,367 @@ func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(params.Body) > 140 {
-		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "Chrip is too long"})
+	cleanedBody, flagged, err := cfg.validateChirpBody(params.Body)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
 		return
 	}
+	params.Body = cleanedBody
 
-	replacedString, _ := replaceProfane(params.Body)
-	params.Body = replacedString
+	// chirps are always posted as the caller, whatever the body says
+	chirpParams := database.CreateChirpParams{
+		Body:   params.Body,
+		UserID: uuid.NullUUID{UUID: viewerID, Valid: true},
+	}
+
+	for _, ref := range []struct {
+		field string
+		raw   string
+		dest  *uuid.NullUUID
+	}{
+		{"in_reply_to", params.InReplyTo, &chirpParams.InReplyTo},
+		{"quote_of", params.QuoteOf, &chirpParams.QuoteOf},
+	} {
+		id, status, err := cfg.parseChirpReference(r.Context(), ref.field, ref.raw)
+		if status == http.StatusInternalServerError {
+			respondWithError(w, status, err)
+			return
+		} else if err != nil {
+			respondWithJSON(w, status, errorReturn{Error: err.Error()})
+			return
+		}
+		*ref.dest = id
+	}
 
-	userUUID, err := uuid.Parse(params.UserID)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), chirpParams)
 
 	if err != nil {
-		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid uuid"})
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't create chirp: %s", err))
 		return
 	}
 
-	chirpParams := database.CreateChirpParams{
-		Body:   params.Body,
-		UserID: uuid.NullUUID{UUID: userUUID, Valid: true},
+	if err := indexChirp(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), chirpParams)
+	if err := flagChirp(r.Context(), qtx, chirp.ID, flagged); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
 
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't create chirp: %s", err))
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit chirp: %s", err))
 		return
 	}
 
-	returnChirp := returnVals{
-		Id:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	returnChirp, err := cfg.chirpToReturn(r.Context(), chirp, uuid.NullUUID{UUID: viewerID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
 	}
 	respondWithJSON(w, http.StatusCreated, returnChirp)
 }
 
-func replaceProfane(s string) (string, bool) {
-	var profane = []string{"kerfuffle", "sharbert", "fornax"}
-	split_sentence := strings.Split(strings.ToLower(s), " ")
-	modified := false
-	for _, p := range profane {
-		for i, w := range split_sentence {
-			if w == p {
-				split_sentence[i] = "****"
-				modified = true
-				break
+// parseChirpReference resolves the id of another chirp named in a request
+// body, such as the chirp being replied to. The returned status tells the
+// caller how to report a failure.
+func (cfg *apiConfig) parseChirpReference(ctx context.Context, field, rawID string) (uuid.NullUUID, int, error) {
+	if rawID == "" {
+		return uuid.NullUUID{}, 0, nil
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.NullUUID{}, http.StatusBadRequest, fmt.Errorf("invalid %s", field)
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, http.StatusBadRequest, fmt.Errorf("%s does not match an existing chirp", field)
+	} else if err != nil {
+		return uuid.NullUUID{}, http.StatusInternalServerError, fmt.Errorf("couldn't look up %s: %s", field, err)
+	}
+
+	if chirp.RechirpOf.Valid {
+		return uuid.NullUUID{}, http.StatusBadRequest, fmt.Errorf("%s can't point at a rechirp", field)
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, 0, nil
+}
+
+// validateChirpBody applies the rules every chirp body has to pass, whether it
+// is being created or edited, and returns the body as it should be stored
+// along with any filtered words that need a moderator to look at it.
+func (cfg *apiConfig) validateChirpBody(body string) (string, []string, error) {
+	if len(body) > 140 {
+		return "", nil, fmt.Errorf("Chrip is too long")
+	}
+
+	verdict := cfg.profanity.Check(body)
+	if len(verdict.Rejected) > 0 {
+		return "", nil, fmt.Errorf("chirp contains a banned word")
+	}
+	return verdict.Text, verdict.Flagged, nil
+}
+
+// reloadProfanityOnHangup reloads the filtered word list every time the
+// process gets SIGHUP, so moderators can change it without a restart.
+func (cfg *apiConfig) reloadProfanityOnHangup() {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	for range hangups {
+		if err := cfg.profanity.Reload(context.Background()); err != nil {
+			log.Printf("couldn't reload filtered words: %s", err)
+			continue
+		}
+		log.Printf("reloaded filtered words")
+	}
+}
+
+// chirpsToReturn converts chirps into their API shape, loading the originals
+// of rechirps and quotes and the per-chirp counts in one query each for the
+// whole batch. liked_by_me is only filled in when viewerID is set.
+func (cfg *apiConfig) chirpsToReturn(ctx context.Context, chirps []database.Chirp, viewerID uuid.NullUUID) ([]returnVals, error) {
+	originalIDs := make([]uuid.UUID, 0)
+	for _, v := range chirps {
+		if ref := originalRef(v); ref.Valid {
+			originalIDs = append(originalIDs, ref.UUID)
+		}
+	}
+
+	originals := make(map[uuid.UUID]database.Chirp, len(originalIDs))
+	if len(originalIDs) > 0 {
+		// originals the viewer can't see are left out and come back as
+		// tombstones, the same as deleted ones
+		rows, err := cfg.dbQueries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+			Ids:      originalIDs,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load original chirps: %s", err)
+		}
+		for _, o := range rows {
+			originals[o.ID] = o
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(chirps)+len(originals))
+	for _, v := range chirps {
+		ids = append(ids, v.ID)
+	}
+	for id := range originals {
+		ids = append(ids, id)
+	}
+
+	replyCounts := make(map[uuid.UUID]int64, len(ids))
+	if len(ids) > 0 {
+		counts, err := cfg.dbQueries.CountRepliesForChirps(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't count replies: %s", err)
+		}
+		for _, c := range counts {
+			replyCounts[c.ChirpID] = c.ReplyCount
+		}
+	}
+
+	likeCounts := make(map[uuid.UUID]int64, len(ids))
+	likedByViewer := make(map[uuid.UUID]bool)
+	if len(ids) > 0 {
+		counts, err := cfg.dbQueries.CountLikesForChirps(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't count likes: %s", err)
+		}
+		for _, c := range counts {
+			likeCounts[c.ChirpID] = c.LikeCount
+		}
+
+		if viewerID.Valid {
+			liked, err := cfg.dbQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+				UserID:   viewerID.UUID,
+				ChirpIds: ids,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("couldn't load likes: %s", err)
+			}
+			for _, id := range liked {
+				likedByViewer[id] = true
+			}
+		}
+	}
+
+	mentions := make(map[uuid.UUID][]mentionEntity)
+	if len(ids) > 0 {
+		rows, err := cfg.dbQueries.GetMentionsForChirps(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load mentions: %s", err)
+		}
+		for _, m := range rows {
+			mentions[m.ChirpID] = append(mentions[m.ChirpID], mentionEntity{
+				Handle: m.Handle,
+				UserID: m.UserID,
+				Start:  int(m.StartOffset),
+				End:    int(m.EndOffset),
+			})
+		}
+	}
+
+	toReturn := func(v database.Chirp) returnVals {
+		chirp := returnVals{
+			Id:         v.ID,
+			CreatedAt:  v.CreatedAt,
+			UpdatedAt:  v.UpdatedAt,
+			Body:       v.Body,
+			UserID:     v.UserID,
+			InReplyTo:  v.InReplyTo,
+			RechirpOf:  v.RechirpOf,
+			QuoteOf:    v.QuoteOf,
+			ReplyCount: replyCounts[v.ID],
+			LikeCount:  likeCounts[v.ID],
+			Entities:   chirpEntitiesFor(v.Body, mentions[v.ID]),
+		}
+		if viewerID.Valid {
+			liked := likedByViewer[v.ID]
+			chirp.LikedByMe = &liked
+		}
+		return chirp
+	}
+
+	resp := make([]returnVals, 0, len(chirps))
+	for _, v := range chirps {
+		chirp := toReturn(v)
+		if ref := originalRef(v); ref.Valid {
+			if o, ok := originals[ref.UUID]; ok {
+				original := toReturn(o)
+				chirp.Original = &originalChirp{returnVals: &original, Id: o.ID}
+			} else {
+				chirp.Original = &originalChirp{Id: ref.UUID, Deleted: true}
 			}
 		}
+		resp = append(resp, chirp)
+	}
+	return resp, nil
+}
+
+// originalRef returns the chirp that v reposts or quotes, if any.
+func originalRef(v database.Chirp) uuid.NullUUID {
+	if v.RechirpOf.Valid {
+		return v.RechirpOf
+	}
+	return v.QuoteOf
+}
+
+func (cfg *apiConfig) chirpToReturn(ctx context.Context, chirp database.Chirp, viewerID uuid.NullUUID) (returnVals, error) {
+	resp, err := cfg.chirpsToReturn(ctx, []database.Chirp{chirp}, viewerID)
+	if err != nil {
+		return returnVals{}, err
+	}
+	return resp[0], nil
+}
+
+func parseChirpFilters(r *http.Request) (database.ListChirpsAscParams, error) {
+	query := r.URL.Query()
+	listParams := database.ListChirpsAscParams{}
+
+	if authorID := query.Get("author_id"); authorID != "" {
+		authorUUID, err := uuid.Parse(authorID)
+		if err != nil {
+			return listParams, fmt.Errorf("invalid author_id")
+		}
+		listParams.AuthorID = uuid.NullUUID{UUID: authorUUID, Valid: true}
+	}
+
+	if since := query.Get("since"); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return listParams, fmt.Errorf("since must be an RFC 3339 timestamp")
+		}
+		listParams.Since = sql.NullTime{Time: sinceTime.UTC(), Valid: true}
 	}
-	return strings.Join(split_sentence, " "), modified
+
+	if until := query.Get("until"); until != "" {
+		untilTime, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return listParams, fmt.Errorf("until must be an RFC 3339 timestamp")
+		}
+		listParams.Until = sql.NullTime{Time: untilTime.UTC(), Valid: true}
+	}
+
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			return listParams, err
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	return listParams, nil
 }
 
 func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
-	chirps, err := cfg.dbQueries.GetAllChirps(r.Context())
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	sortOrder := r.URL.Query().Get("sort")
+	if sortOrder == "" {
+		sortOrder = "asc"
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "sort must be asc or desc"})
+		return
+	}
+
+	listParams, err := parseChirpFilters(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	// one extra row tells us whether there is another page without a count query
+	listParams.PageLimit = int32(limit + 1)
+	// shadow-banned users' chirps are hidden from everyone but themselves
+	viewerID := cfg.optionalUserID(r)
+	listParams.ViewerID = viewerID
+
+	var chirps []database.Chirp
+	if sortOrder == "desc" {
+		chirps, err = cfg.dbQueries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams(listParams))
+	} else {
+		chirps, err = cfg.dbQueries.ListChirpsAsc(r.Context(), listParams)
+	}
 	if err != nil {
 		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query faild"})
+		return
 	}
 
-	resp := make([]returnVals, 0, len(chirps))
+	nextCursor := ""
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
 
-	for _, v := range chirps {
-		resp = append(resp, returnVals{
-			Id:        v.ID,
-			CreatedAt: v.CreatedAt,
-			UpdatedAt: v.UpdatedAt,
-			Body:      v.Body,
-			UserID:    v.UserID,
-		})
+	resp, err := cfg.chirpsToReturn(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, resp)
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: resp, NextCursor: nextCursor})
 }
 func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 
@@ -295,14 +694,25 @@ func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpId)
+	// a shadow-banned author's chirps look missing to everyone but them
+	viewerID := cfg.optionalUserID(r)
+	chirp, err := cfg.dbQueries.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpId,
+		ViewerID: viewerID,
+	})
 
 	if err != nil {
 		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "query faild"})
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirp)
+	returnChirp, err := cfg.chirpToReturn(r.Context(), chirp, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, returnChirp)
 
 }
 
@@ -326,14 +736,47 @@ func (cfg *apiConfig) logIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := getToken(user.ID, cfg.tokenSecret)
+	// only said once the password checks out, so it doesn't reveal which
+	// emails belong to suspended accounts
+	if user.SuspendedAt.Valid {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errAccountSuspended.Error()})
+		return
+	}
+
+	// with two-factor on, the password only earns a challenge token, which
+	// POST /api/login/2fa swaps for real tokens along with a code
+	credential, err := cfg.dbQueries.GetTOTPCredential(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't look up two-factor settings: %s", err))
+		return
+	}
+	if err == nil && credential.EnabledAt.Valid {
+		challenge, err := auth.MakeChallengeJWT(user.ID, cfg.keys, twoFactorChallengeTTL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, twoFactorChallengeReturn{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		})
+		return
+	}
+
+	cfg.signIn(w, r, user)
+}
+
+// signIn responds with a new access token and refresh token for user, once
+// they have proved who they are.
+func (cfg *apiConfig) signIn(w http.ResponseWriter, r *http.Request, user database.User) {
+	sessionID, refreshToken, err := cfg.startSession(r, user.ID)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	refreshToken, err := getRefreshToken(r.Context(), cfg, user.ID)
+	token, err := getToken(user, sessionID, cfg.keys)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
@@ -341,59 +784,105 @@ func (cfg *apiConfig) logIn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	returnVals := userReturn{
-		Id:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        token,
-		RefreshToken: refreshToken.Token,
-		IsChirpyRed:  user.IsChirpyRed,
+		Id:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Location:      user.Location,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   user.IsChirpyRed,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt.Valid,
 	}
 
 	respondWithJSON(w, http.StatusOK, returnVals)
-
 }
 
-func getRefreshToken(context context.Context, cfg *apiConfig, userID uuid.UUID) (database.RefreshToken, error) {
-	refreshTokenCode, err := auth.MakeRefreshToken()
+func getToken(user database.User, sessionID uuid.UUID, keys *auth.Keyring) (string, error) {
+	token, err := auth.MakeJWT(user.ID, auth.Role(user.Role), sessionID, keys, time.Duration(3600)*time.Second)
 
 	if err != nil {
-		return database.RefreshToken{}, err
+		return "", err
 	}
+	return token, nil
+}
 
-	refreshTokenParams := database.CreateRefreshTokenParams{
-		Token: refreshTokenCode,
-		UserID: uuid.NullUUID{
-			UUID:  userID,
-			Valid: true,
-		},
-		ExpiresAt: time.Now().Add(time.Duration(60*60*24*60) * time.Second),
+// optionalUserID returns the caller's id when the request carries a valid
+// access token. Endpoints that are public but personalise their response use
+// it, so a missing or bad token just means an anonymous caller.
+func (cfg *apiConfig) optionalUserID(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
 	}
 
-	refreshToken, err := cfg.dbQueries.CreateRefreshToken(context, refreshTokenParams)
-
+	userID, err := cfg.tokens.Validate(token)
 	if err != nil {
-		return database.RefreshToken{}, err
+		return uuid.NullUUID{}
 	}
-	return refreshToken, nil
+	return uuid.NullUUID{UUID: userID, Valid: true}
 }
 
-func getToken(userID uuid.UUID, tokenSecret string) (string, error) {
-	token, err := auth.MakeJWT(userID, tokenSecret, time.Duration(3600)*time.Second)
+// errAccountSuspended is what a suspended user gets back from anything that
+// needs them signed in.
+var errAccountSuspended = errors.New("account suspended")
 
+// errEmailUnverified is returned to accounts the email policy won't let post.
+var errEmailUnverified = errors.New("verify your email address before posting")
+
+// authenticatedUser validates the request's access token and loads the
+// caller. Suspended accounts are turned away even while their access tokens
+// are still unexpired. When it returns false the response has already been
+// written.
+func (cfg *apiConfig) authenticatedUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		return "", err
+		respondWithAuthError(w, http.StatusUnauthorized, err)
+		return database.User{}, false
 	}
-	return token, nil
+	userID, err := cfg.tokens.Validate(token)
+	if err != nil {
+		respondWithAuthError(w, http.StatusUnauthorized, err)
+		return database.User{}, false
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithAuthError(w, http.StatusUnauthorized, fmt.Errorf("%w: user %s", auth.ErrUnknownSubject, userID))
+		return database.User{}, false
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't look up user: %s", err))
+		return database.User{}, false
+	}
+
+	if user.SuspendedAt.Valid {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errAccountSuspended.Error()})
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// authenticate is authenticatedUser for handlers that only need the caller's
+// id.
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	user, ok := cfg.authenticatedUser(w, r)
+	return user.ID, ok
 }
 
 func checkRefreshToken(cfg *apiConfig, ctx context.Context, token string) (database.RefreshToken, error) {
-	refreshToken, err := cfg.dbQueries.GetRefreshToken(ctx, token)
+	refreshToken, err := cfg.dbQueries.GetRefreshToken(ctx, auth.HashToken(token))
 	if err != nil {
 		return database.RefreshToken{}, err
 	}
-	log.Println(refreshToken.ExpiresAt, "\n", time.Now(), "\n", refreshToken.ExpiresAt.Before(time.Now()), "////////////////////////////////")
 
+	if refreshToken.RotatedAt.Valid {
+		// the caller needs the token to revoke the rest of its family
+		return refreshToken, errRefreshTokenReused
+	}
 	if refreshToken.ExpiresAt.Before(time.Now()) {
 		// handle expired token, e.g., return an error
 		return database.RefreshToken{}, fmt.Errorf("refresh token has expired")
@@ -413,19 +902,50 @@ func (cfg *apiConfig) refreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	refreshToken, err := checkRefreshToken(cfg, r.Context(), token)
+	if errors.Is(err, errRefreshTokenReused) {
+		cfg.refreshTokenReused(w, r, refreshToken)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Errorf("refresh token failed: %v", err))
+		return
+	}
 
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), refreshToken.UserID.UUID)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, fmt.Errorf("refresh token failed: %v", err))
 		return
 	}
+	if user.SuspendedAt.Valid {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errAccountSuspended.Error()})
+		return
+	}
+
+	nextRefreshToken, err := cfg.rotateRefreshToken(r, refreshToken)
+	if errors.Is(err, errRefreshTokenReused) {
+		cfg.refreshTokenReused(w, r, refreshToken)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
 
-	newToken, err := auth.MakeJWT(refreshToken.UserID.UUID, cfg.tokenSecret, time.Duration(60*60)*time.Second)
+	newToken, err := getToken(user, refreshToken.FamilyID, cfg.keys)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, RefreshTokenReturn{Token: newToken})
+	respondWithJSON(w, http.StatusOK, RefreshTokenReturn{Token: newToken, RefreshToken: nextRefreshToken})
+}
+
+func (cfg *apiConfig) refreshTokenReused(w http.ResponseWriter, r *http.Request, reused database.RefreshToken) {
+	if err := cfg.revokeTokenFamily(r.Context(), reused); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, fmt.Errorf("refresh token failed: %v", errRefreshTokenReused))
 }
 
 func (cfg *apiConfig) revokeRefresh(w http.ResponseWriter, r *http.Request) {
@@ -436,7 +956,7 @@ func (cfg *apiConfig) revokeRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := cfg.dbQueries.RevokeRefreshToken(r.Context(), token); err != nil {
+	if err := cfg.dbQueries.RevokeRefreshToken(r.Context(), auth.HashToken(token)); err != nil {
 		respondWithError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -448,12 +968,11 @@ func (cfg *apiConfig) revokeRefresh(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
 		return
 	}
+	userID := user.ID
 
 	params := userParams{}
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
@@ -461,54 +980,97 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
+	if params.Email == "" || params.Password == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "email and password are required"})
 		return
+
 	}
 
-	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	passwordChanged := auth.CheckPasswordHash(params.Password, user.HashedPassword) != nil
+	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, fmt.Errorf("user not found: %s", err))
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "password hashing failed"})
 		return
 	}
 
-	if params.Email == "" || params.Password == "" {
-		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "email and password are required"})
+	profile, status, err := cfg.profileUpdate(r.Context(), params, user)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		respondWithJSON(w, status, errorReturn{Error: err.Error()})
 		return
-
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "password hashing failed"})
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
 	// Update user in the database
 	updateParams := database.UpdateUserParams{
 		ID:             userID,
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
 	}
-	if _, err := cfg.dbQueries.UpdateUser(r.Context(), updateParams); err != nil {
+	updated, err := qtx.UpdateUser(r.Context(), updateParams)
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update user: %s", err))
 		return
 	}
 
-	newUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve updated user: %s", err))
+	// UpdateUser marks a new address unverified; it needs confirming again
+	verificationToken := ""
+	if updated.Email != user.Email {
+		verificationToken, err = issueEmailVerification(r.Context(), qtx, userID, updated.Email)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if _, err := qtx.UpdateUserProfile(r.Context(), profile); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update profile: %s", err))
 		return
 	}
 
-	newToken, err := getToken(user.ID, cfg.tokenSecret)
+	// whoever knew the old password may be signed in somewhere else
+	if passwordChanged {
+		if err := cfg.revokeOtherSessions(r, qtx, userID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't revoke sessions: %s", err))
+			return
+		}
+	}
 
-	if err != nil {
+	// the new tokens carry on the session the request came from rather than
+	// signing the device in a second time
+	sessionID, refreshToken, err := cfg.continueSession(r, qtx, userID)
+	if errors.Is(err, errSessionEnded) {
+		respondWithAuthError(w, http.StatusUnauthorized, err)
+		return
+	} else if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	refreshToken, err := getRefreshToken(r.Context(), cfg, user.ID)
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit user: %s", err))
+		return
+	}
+	if verificationToken != "" {
+		cfg.sendVerificationEmail(updated.Email, verificationToken)
+	}
+
+	newUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve updated user: %s", err))
+		return
+	}
+
+	newToken, err := getToken(newUser, sessionID, cfg.keys)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err)
@@ -516,27 +1078,26 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	returnVals := userReturn{
-		Id:           newUser.ID,
-		CreatedAt:    newUser.CreatedAt,
-		UpdatedAt:    newUser.UpdatedAt,
-		Email:        newUser.Email,
-		Token:        newToken,
-		RefreshToken: refreshToken.Token,
-		IsChirpyRed:  newUser.IsChirpyRed,
+		Id:            newUser.ID,
+		CreatedAt:     newUser.CreatedAt,
+		UpdatedAt:     newUser.UpdatedAt,
+		Email:         newUser.Email,
+		Handle:        newUser.Handle,
+		DisplayName:   newUser.DisplayName,
+		Bio:           newUser.Bio,
+		Location:      newUser.Location,
+		Token:         newToken,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   newUser.IsChirpyRed,
+		Role:          newUser.Role,
+		EmailVerified: newUser.EmailVerifiedAt.Valid,
 	}
 	respondWithJSON(w, http.StatusOK, returnVals)
 }
 
 func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err)
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
 		return
 	}
 	parts := strings.Split(r.URL.Path, "/")
@@ -605,11 +1166,17 @@ func (cfg *apiConfig) upgradeToRed(w http.ResponseWriter, r *http.Request) {
 	}
 
 	returnVals := userReturn{
-		Id:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+		Id:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Location:      user.Location,
+		IsChirpyRed:   user.IsChirpyRed,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt.Valid,
 	}
 
 	respondWithJSON(w, http.StatusNoContent, returnVals)
@@ -624,33 +1191,134 @@ func main() {
 	}
 	dbQueries := database.New(db)
 
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), db, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	const filepathRoot = "."
 	const port = "8080"
 	myApiConfig := apiConfig{
 		fileserverHits: atomic.Int32{},
+		db:             db,
 		dbQueries:      dbQueries,
 		platform:       os.Getenv("PLATFORM"),
-		tokenSecret:    os.Getenv("JWT_SECRET"),
+		keys:           &auth.Keyring{},
 		polkaKey:       os.Getenv("POLKA_KEY"),
+		trending:       &trendingCache{},
+		profanityFile:  os.Getenv("PROFANITY_FILE"),
+		baseURL:        os.Getenv("BASE_URL"),
+
+		requireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+	}
+	if myApiConfig.baseURL == "" {
+		myApiConfig.baseURL = "http://localhost:" + port
+	}
+
+	myApiConfig.keyRotation, err = keyRotationFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	myApiConfig.keySealer, err = keySealerFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	myApiConfig.tokens, err = auth.NewValidator(myApiConfig.keys, auth.ValidatorOptions{
+		Algorithms:     []string{"EdDSA"},
+		Issuer:         auth.Issuer,
+		Audience:       auth.Audience,
+		Leeway:         tokenLeeway,
+		RequiredClaims: []string{"exp", "iat", "sub"},
+	})
+	if err != nil {
+		log.Fatalf("couldn't set up token validation: %s", err)
+	}
+	if err := myApiConfig.rotateKeys(context.Background()); err != nil {
+		log.Fatalf("couldn't load signing keys: %s", err)
+	}
+	go myApiConfig.runKeyRotation(context.Background())
+
+	myApiConfig.mailer, err = mailerFromEnv()
+	if err != nil {
+		log.Fatalf("couldn't set up mail: %s", err)
 	}
 
+	// the word list comes from the filtered_terms table unless a file is
+	// configured. Either way, SIGHUP reloads it without a restart.
+	var wordSource profanity.Source = dbFilteredTerms(dbQueries)
+	if myApiConfig.profanityFile != "" {
+		wordSource = profanity.FileSource(myApiConfig.profanityFile)
+	}
+	myApiConfig.profanity = profanity.New(wordSource)
+	if err := myApiConfig.profanity.Reload(context.Background()); err != nil {
+		log.Fatalf("couldn't load filtered words: %s", err)
+	}
+	go myApiConfig.reloadProfanityOnHangup()
+
+	go myApiConfig.runTrendingJob(context.Background())
+
 	mux := http.NewServeMux()
 	mux.Handle("/app/", http.StripPrefix("/app/", myApiConfig.middlewareMetricsIncrease(http.FileServer(http.Dir(filepathRoot)))))
+	mux.HandleFunc("GET /.well-known/jwks.json", myApiConfig.getJWKS)
 	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-type", "text/plain; charset=utf-8")
 		w.WriteHeader(200)
 		w.Write([]byte("OK"))
 	})
-	mux.HandleFunc("GET /admin/metrics", myApiConfig.returnHits)
-	mux.HandleFunc("POST /admin/reset", myApiConfig.reset)
+	mux.Handle("GET /admin/metrics", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.returnHits))
+	mux.Handle("POST /admin/reset", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.reset))
+	mux.Handle("GET /admin/filtered-terms", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.listFilteredTerms))
+	mux.Handle("POST /admin/filtered-terms", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.putFilteredTerm))
+	mux.Handle("DELETE /admin/filtered-terms/{term}", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.deleteFilteredTerm))
+	mux.Handle("GET /admin/reports", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.listReports))
+	mux.Handle("POST /admin/reports/{id}/claim", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.claimReport))
+	mux.Handle("POST /admin/reports/{id}/resolve", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.resolveReport))
+	mux.Handle("GET /admin/audit-log", myApiConfig.requireRole(auth.RoleAdmin, myApiConfig.listAuditLog))
+	mux.Handle("POST /admin/users/{id}/suspend", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.suspendUser))
+	mux.Handle("DELETE /admin/users/{id}/suspend", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.unsuspendUser))
+	mux.Handle("POST /admin/users/{id}/shadow-ban", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.shadowBanUser))
+	mux.Handle("DELETE /admin/users/{id}/shadow-ban", myApiConfig.requireRole(auth.RoleModerator, myApiConfig.unshadowBanUser))
 	mux.HandleFunc("POST /api/users", myApiConfig.createUser)
+	mux.HandleFunc("POST /api/password/forgot", myApiConfig.forgotPassword)
+	mux.HandleFunc("POST /api/password/reset", myApiConfig.resetPassword)
+	mux.HandleFunc("GET /api/verify-email", myApiConfig.verifyEmail)
+	mux.HandleFunc("POST /api/verify-email/resend", myApiConfig.resendVerification)
 	mux.HandleFunc("POST /api/chirps", myApiConfig.createChirp)
 	mux.HandleFunc("GET /api/chirps", myApiConfig.getAllChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpid}", myApiConfig.getChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpid}", myApiConfig.updateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpid}", myApiConfig.deleteChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpid}/revisions", myApiConfig.getChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpid}/thread", myApiConfig.getChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpid}/rechirp", myApiConfig.createRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpid}/rechirp", myApiConfig.deleteRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpid}/likes", myApiConfig.likeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpid}/likes", myApiConfig.unlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpid}/reports", myApiConfig.reportChirp)
+	mux.HandleFunc("GET /api/users/{handle}", myApiConfig.getProfile)
+	mux.HandleFunc("GET /api/users/{id}/likes", myApiConfig.getUserLikes)
+	mux.HandleFunc("POST /api/users/{id}/follow", myApiConfig.followUser)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", myApiConfig.unfollowUser)
+	mux.HandleFunc("GET /api/users/{id}/followers", myApiConfig.getFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", myApiConfig.getFollowing)
+	mux.HandleFunc("GET /api/timeline", myApiConfig.getTimeline)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", myApiConfig.getHashtagChirps)
+	mux.HandleFunc("GET /api/trending", myApiConfig.getTrending)
+	mux.HandleFunc("GET /api/mentions", myApiConfig.getMentions)
+	mux.HandleFunc("GET /api/search/chirps", myApiConfig.searchChirps)
+	mux.HandleFunc("GET /api/search/users", myApiConfig.searchUsers)
 	mux.HandleFunc("POST /api/login", myApiConfig.logIn)
+	mux.HandleFunc("POST /api/login/2fa", myApiConfig.logInTwoFactor)
+	mux.HandleFunc("POST /api/2fa/enroll", myApiConfig.enrollTwoFactor)
+	mux.HandleFunc("POST /api/2fa/confirm", myApiConfig.confirmTwoFactor)
+	mux.HandleFunc("POST /api/2fa/disable", myApiConfig.disableTwoFactor)
 	mux.HandleFunc("POST /api/refresh", myApiConfig.refreshToken)
 	mux.HandleFunc("POST /api/revoke", myApiConfig.revokeRefresh)
+	mux.HandleFunc("GET /api/sessions", myApiConfig.listSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", myApiConfig.deleteSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", myApiConfig.revokeAllSessions)
 	mux.HandleFunc("PUT /api/users", myApiConfig.updateUser)
 	mux.HandleFunc("PUT /api/polka/webhooks", myApiConfig.upgradeToRed)
 
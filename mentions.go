@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/entities"
+)
+
+// chirpEntities tells clients where the links are in a chirp body. Offsets
+// count characters, not bytes.
+type chirpEntities struct {
+	Hashtags []hashtagEntity `json:"hashtags"`
+	Mentions []mentionEntity `json:"mentions"`
+}
+
+type hashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type mentionEntity struct {
+	Handle string    `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+}
+
+// chirpEntitiesFor finds the hashtags in body. Mentions are the ones stored
+// when the chirp was saved, so they keep pointing at the same user whatever
+// handles are changed to later.
+func chirpEntitiesFor(body string, mentions []mentionEntity) chirpEntities {
+	if mentions == nil {
+		mentions = []mentionEntity{}
+	}
+	result := chirpEntities{Hashtags: []hashtagEntity{}, Mentions: mentions}
+
+	for _, tag := range entities.Hashtags(body) {
+		result.Hashtags = append(result.Hashtags, hashtagEntity{
+			Tag:   entities.NormalizeHashtag(tag.Text),
+			Start: tag.Start,
+			End:   tag.End,
+		})
+	}
+	return result
+}
+
+// indexChirpEntities records the hashtags and mentions in the chirp's body.
+// Like indexHashtags it replaces what was there, so edits can call it again.
+func indexChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := indexHashtags(ctx, q, chirp.ID, chirp.Body); err != nil {
+		return err
+	}
+
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return fmt.Errorf("couldn't clear mentions: %s", err)
+	}
+
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, entities.NormalizeHandle(mention.Text))
+	}
+
+	// handles nobody has claimed are simply not linked
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return fmt.Errorf("couldn't resolve mentions: %s", err)
+	}
+
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[entities.NormalizeHandle(user.Handle)] = user.ID
+	}
+
+	// every mention is saved as written and where it was written
+	for _, mention := range mentions {
+		userID, ok := userIDs[entities.NormalizeHandle(mention.Text)]
+		if !ok {
+			continue
+		}
+		if err := q.AddChirpMention(ctx, database.AddChirpMentionParams{
+			UserID:      userID,
+			Handle:      mention.Text,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+			ChirpID:     chirp.ID,
+		}); err != nil {
+			return fmt.Errorf("couldn't save mention: %s", err)
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getMentions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	listParams := database.ListMentioningChirpsParams{
+		UserID:    userID,
+		PageLimit: int32(limit + 1),
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.ListMentioningChirps(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	resp, err := cfg.chirpsToReturn(r.Context(), chirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: resp, NextCursor: nextCursor})
+}
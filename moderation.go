@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/profanity"
+)
+
+type filteredTermParams struct {
+	Term   string `json:"term"`
+	Action string `json:"action"`
+}
+
+type filteredTermReturn struct {
+	Term      string    `json:"term"`
+	CreatedAt time.Time `json:"created_at"`
+	Action    string    `json:"action"`
+}
+
+// dbFilteredTerms reads the word list from the filtered_terms table.
+func dbFilteredTerms(q *database.Queries) profanity.Source {
+	return profanity.SourceFunc(func(ctx context.Context) ([]profanity.Term, error) {
+		rows, err := q.ListFilteredTerms(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		terms := make([]profanity.Term, 0, len(rows))
+		for _, v := range rows {
+			terms = append(terms, profanity.Term{Word: v.Term, Action: profanity.Action(v.Action)})
+		}
+		return terms, nil
+	})
+}
+
+// flagChirp puts the chirp in the moderation queue once per flagged word.
+func flagChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID, terms []string) error {
+	for _, term := range terms {
+		if err := q.FlagChirp(ctx, database.FlagChirpParams{ChirpID: chirpID, Term: term}); err != nil {
+			return fmt.Errorf("couldn't flag chirp: %s", err)
+		}
+	}
+	return nil
+}
+
+// termsInFile refuses edits to the filtered_terms table while the word list
+// is read from a file, since they would silently have no effect.
+func (cfg *apiConfig) termsInFile(w http.ResponseWriter) bool {
+	if cfg.profanityFile == "" {
+		return false
+	}
+	respondWithJSON(w, http.StatusConflict, errorReturn{Error: "the word list is managed in PROFANITY_FILE"})
+	return true
+}
+
+func (cfg *apiConfig) listFilteredTerms(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.dbQueries.ListFilteredTerms(r.Context())
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	terms := make([]filteredTermReturn, 0, len(rows))
+	for _, v := range rows {
+		terms = append(terms, filteredTermReturn(v))
+	}
+	respondWithJSON(w, http.StatusOK, terms)
+}
+
+func (cfg *apiConfig) putFilteredTerm(w http.ResponseWriter, r *http.Request) {
+	if cfg.termsInFile(w) {
+		return
+	}
+
+	params := filteredTermParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	term := strings.ToLower(strings.TrimSpace(params.Term))
+	if !profanity.ValidWord(term) {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("term must be a single word of at most %d characters", profanity.MaxTermLength)})
+		return
+	}
+
+	if params.Action == "" {
+		params.Action = string(profanity.ActionMask)
+	}
+	action, err := profanity.ParseAction(params.Action)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	saved, err := cfg.dbQueries.UpsertFilteredTerm(r.Context(), database.UpsertFilteredTermParams{
+		Term:   term,
+		Action: string(action),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't save filtered term: %s", err))
+		return
+	}
+
+	if err := cfg.profanity.Reload(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, filteredTermReturn(saved))
+}
+
+func (cfg *apiConfig) deleteFilteredTerm(w http.ResponseWriter, r *http.Request) {
+	if cfg.termsInFile(w) {
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteFilteredTerm(r.Context(), strings.ToLower(r.PathValue("term")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't delete filtered term: %s", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "term not found"})
+		return
+	}
+
+	if err := cfg.profanity.Reload(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor is the keyset position of the last item on a page. It is handed
+// to clients as an opaque string so the encoding can change without breaking
+// them.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := fmt.Sprintf("%d:%s", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	// created_at is a TIMESTAMP without time zone, so the wall clock has to be
+	// handed back in UTC to compare equal to the stored value.
+	return pageCursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id}, nil
+}
+
+// encodeOffsetCursor is for lists with no stable keyset to page on, like
+// search results ordered by relevance.
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeOffsetCursor(s string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	rawOffset, ok := strings.CutPrefix(string(raw), "offset:")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+func parsePageLimit(s string) (int, error) {
+	if s == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive integer")
+	}
+
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit, nil
+}
+
+// setNextLink points the Link header at the next page, keeping every other
+// query parameter of the current request.
+func setNextLink(w http.ResponseWriter, r *http.Request, nextCursor string, limit int) {
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+	query.Set("limit", strconv.Itoa(limit))
+
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/mailer"
+)
+
+// passwordResetTTL is how long a reset code works for.
+const passwordResetTTL = time.Hour
+
+type forgotPasswordParams struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordParams struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (cfg *apiConfig) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	params := forgotPasswordParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+	params.Email = strings.TrimSpace(params.Email)
+	if params.Email == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "email is required"})
+		return
+	}
+
+	// the lookup and the email happen after responding, so neither the
+	// response nor how long it takes shows whether the email has an account
+	go cfg.sendPasswordReset(params.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset emails a reset code to the account with the given email,
+// if there is one. It runs in the background, so failures are only logged.
+func (cfg *apiConfig) sendPasswordReset(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	user, err := cfg.dbQueries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	} else if err != nil {
+		log.Printf("couldn't look up user for password reset: %s", err)
+		return
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("couldn't make password reset token: %s", err)
+		return
+	}
+
+	if err := cfg.dbQueries.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		log.Printf("couldn't save reset token: %s", err)
+		return
+	}
+
+	if err := cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"To choose a new one, send this code to POST /api/password/reset within %d minutes:\n\n%s\n\n"+
+			"If it wasn't you, you can ignore this email; your password hasn't changed.\n",
+			int(passwordResetTTL.Minutes()), token),
+	}); err != nil {
+		log.Printf("couldn't send password reset to %s: %s", user.Email, err)
+	}
+}
+
+func (cfg *apiConfig) resetPassword(w http.ResponseWriter, r *http.Request) {
+	params := resetPasswordParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+	if params.Token == "" || params.Password == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "token and password are required"})
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "password hashing failed"})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// marking the token used and reading it back in one statement means two
+	// requests racing with the same token can't both succeed
+	userID, err := qtx.UsePasswordReset(r.Context(), auth.HashToken(params.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid or expired reset token"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't check reset token: %s", err))
+		return
+	}
+
+	if err := qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		HashedPassword: hashedPassword,
+		ID:             userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't update password: %s", err))
+		return
+	}
+
+	// any other codes that were sent stop working, and whoever knew the old
+	// password is signed out
+	if err := qtx.ExpirePasswordResets(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't expire reset tokens: %s", err))
+		return
+	}
+	if err := qtx.RevokeUserRefreshTokens(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't revoke refresh tokens: %s", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit password reset: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
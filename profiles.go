@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/entities"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+)
+
+// profileReturn is what anyone can see about a user. It deliberately has no
+// email field, so it can't leak one by accident.
+type profileReturn struct {
+	Id             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// checkHandle makes sure handle is well formed and not taken by anyone other
+// than exceptUserID. On failure it returns the status to respond with.
+func (cfg *apiConfig) checkHandle(ctx context.Context, handle string, exceptUserID uuid.UUID) (int, error) {
+	if !entities.ValidHandle(handle) {
+		return http.StatusBadRequest, fmt.Errorf("handle must be 1 to %d letters, digits or underscores", entities.MaxHandleLength)
+	}
+
+	users, err := cfg.dbQueries.GetUsersByHandles(ctx, []string{entities.NormalizeHandle(handle)})
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("query failed")
+	}
+	for _, user := range users {
+		if user.ID != exceptUserID {
+			return http.StatusBadRequest, errors.New("handle already taken")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// cleanProfileText trims value and checks it fits in maxLength characters.
+// Only the bio may span several lines.
+func cleanProfileText(field, value string, maxLength int, multiline bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > maxLength {
+		return "", fmt.Errorf("%s must be at most %d characters", field, maxLength)
+	}
+	for _, r := range value {
+		if r == '\n' && multiline {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("%s contains invalid characters", field)
+		}
+	}
+	return value, nil
+}
+
+// profileUpdate validates the profile fields in params and merges them over
+// current. Fields left out of the request keep their current value. On
+// failure it returns the status to respond with.
+func (cfg *apiConfig) profileUpdate(ctx context.Context, params userParams, current database.User) (database.UpdateUserProfileParams, int, error) {
+	profile := database.UpdateUserProfileParams{
+		Handle:      current.Handle,
+		DisplayName: current.DisplayName,
+		Bio:         current.Bio,
+		Location:    current.Location,
+		ID:          current.ID,
+	}
+
+	if params.Handle != "" && params.Handle != current.Handle {
+		if status, err := cfg.checkHandle(ctx, params.Handle, current.ID); err != nil {
+			return profile, status, err
+		}
+		profile.Handle = params.Handle
+	}
+
+	for _, field := range []struct {
+		name      string
+		value     *string
+		maxLength int
+		multiline bool
+		dest      *string
+	}{
+		{"display_name", params.DisplayName, maxDisplayNameLength, false, &profile.DisplayName},
+		{"bio", params.Bio, maxBioLength, true, &profile.Bio},
+		{"location", params.Location, maxLocationLength, false, &profile.Location},
+	} {
+		if field.value == nil {
+			continue
+		}
+		cleaned, err := cleanProfileText(field.name, *field.value, field.maxLength, field.multiline)
+		if err != nil {
+			return profile, http.StatusBadRequest, err
+		}
+		*field.dest = cleaned
+	}
+
+	return profile, http.StatusOK, nil
+}
+
+func (cfg *apiConfig) getProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := cfg.dbQueries.GetProfileByHandle(r.Context(), r.PathValue("handle"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profileReturn{
+		Id:             profile.ID,
+		CreatedAt:      profile.CreatedAt,
+		Handle:         profile.Handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		Location:       profile.Location,
+		IsChirpyRed:    profile.IsChirpyRed,
+		FollowerCount:  profile.FollowerCount,
+		FollowingCount: profile.FollowingCount,
+	})
+}
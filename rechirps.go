@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) createRechirp(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+	userID := user.ID
+
+	if !cfg.canPost(user) {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errEmailUnverified.Error()})
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	original, err := cfg.dbQueries.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpId,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "chirp not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	// rechirping a rechirp shares the chirp it points at
+	if original.RechirpOf.Valid {
+		chirpId = original.RechirpOf.UUID
+	}
+
+	rechirpParams := database.GetRechirpParams{UserID: userID, RechirpOf: chirpId}
+
+	// inserting first means two requests at once can't both see no rechirp
+	// and then collide; whichever loses gets the one the other made
+	status := http.StatusCreated
+	rechirp, err := cfg.dbQueries.CreateRechirp(r.Context(), database.CreateRechirpParams(rechirpParams))
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusOK
+		rechirp, err = cfg.dbQueries.GetRechirp(r.Context(), rechirpParams)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't rechirp: %s", err))
+		return
+	}
+
+	returnChirp, err := cfg.chirpToReturn(r.Context(), rechirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondWithJSON(w, status, returnChirp)
+}
+
+func (cfg *apiConfig) deleteRechirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	// the original may already be gone, so this deliberately doesn't look it up
+	if err := cfg.dbQueries.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: chirpId,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't undo rechirp: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
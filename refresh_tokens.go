@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+const (
+	// refreshTokenTTL is how long a refresh token lasts if it is never used.
+	// Every use replaces it with a new one, so an active session never expires.
+	refreshTokenTTL = 60 * 24 * time.Hour
+
+	securityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+var errRefreshTokenReused = errors.New("refresh token has already been used")
+
+// issueRefreshToken creates a refresh token in the given family and returns
+// it. Every token descended from the same log in shares a family. Only a hash
+// of the token is stored, so whoever reads the database can't use it.
+func issueRefreshToken(ctx context.Context, q *database.Queries, userID, familyID uuid.UUID) (string, error) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    uuid.NullUUID{UUID: userID, Valid: true},
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		FamilyID:  familyID,
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// rotateRefreshToken marks old as used and issues its replacement, noting the
+// device r came from against the session. If another request rotated old
+// first, it returns errRefreshTokenReused.
+func (cfg *apiConfig) rotateRefreshToken(r *http.Request, old database.RefreshToken) (string, error) {
+	ctx := r.Context()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	rotated, err := qtx.RotateRefreshToken(ctx, old.TokenHash)
+	if err != nil {
+		return "", fmt.Errorf("couldn't rotate refresh token: %s", err)
+	}
+	if rotated == 0 {
+		return "", errRefreshTokenReused
+	}
+
+	userAgent, ip := clientDevice(r)
+	if err := qtx.TouchSession(ctx, database.TouchSessionParams{
+		ID:        old.FamilyID,
+		UserAgent: userAgent,
+		Ip:        ip,
+	}); err != nil {
+		return "", fmt.Errorf("couldn't update session: %s", err)
+	}
+
+	next, err := issueRefreshToken(ctx, qtx, old.UserID.UUID, old.FamilyID)
+	if err != nil {
+		return "", fmt.Errorf("couldn't issue refresh token: %s", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return next, nil
+}
+
+// revokeTokenFamily handles a refresh token being presented after it was
+// rotated. Either the client or an attacker holds a copy it shouldn't, and
+// there's no telling which, so every token in the family stops working and
+// the user has to log in again. Only the reuse that revokes the family is
+// logged and recorded; replaying its tokens after that changes nothing.
+func (cfg *apiConfig) revokeTokenFamily(ctx context.Context, reused database.RefreshToken) error {
+	revoked, err := cfg.dbQueries.RevokeRefreshTokenFamily(ctx, reused.FamilyID)
+	if err != nil {
+		return fmt.Errorf("couldn't revoke refresh token family: %s", err)
+	}
+	if revoked == 0 {
+		return nil
+	}
+
+	log.Printf("refresh token reused for user %s: revoked family %s", reused.UserID.UUID, reused.FamilyID)
+
+	if err := cfg.dbQueries.CreateSecurityEvent(ctx, database.CreateSecurityEventParams{
+		UserID:  reused.UserID,
+		Kind:    securityEventRefreshTokenReuse,
+		Details: fmt.Sprintf("family %s", reused.FamilyID),
+	}); err != nil {
+		return fmt.Errorf("couldn't record security event: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusClaimed  = "claimed"
+	reportStatusResolved = "resolved"
+
+	resolutionDismiss       = "dismiss"
+	resolutionDeleteChirp   = "delete_chirp"
+	resolutionSuspendAuthor = "suspend_author"
+
+	maxReportDetailsLength = 500
+)
+
+// reportReasons are the reason codes users can pick from. The word filter
+// files its reports as "filtered_term", which users can't.
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual_content": true,
+	"misinformation": true,
+	"other":          true,
+}
+
+type reportParams struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type resolveParams struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+type reportReturn struct {
+	Id            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	ChirpID       uuid.NullUUID `json:"chirp_id"`
+	ChirpAuthorID uuid.NullUUID `json:"chirp_author_id"`
+	ReporterID    uuid.NullUUID `json:"reporter_id"`
+	Reason        string        `json:"reason"`
+	Details       string        `json:"details"`
+	Status        string        `json:"status"`
+	ClaimedBy     uuid.NullUUID `json:"claimed_by"`
+	ClaimedAt     *time.Time    `json:"claimed_at"`
+	ResolvedBy    uuid.NullUUID `json:"resolved_by"`
+	ResolvedAt    *time.Time    `json:"resolved_at"`
+	Resolution    *string       `json:"resolution"`
+}
+
+type reportsPage struct {
+	Reports    []reportReturn `json:"reports"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type auditEntryReturn struct {
+	Id           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ActorID      uuid.NullUUID `json:"actor_id"`
+	Action       string        `json:"action"`
+	ReportID     uuid.NullUUID `json:"report_id"`
+	ChirpID      uuid.NullUUID `json:"chirp_id"`
+	TargetUserID uuid.NullUUID `json:"target_user_id"`
+	Details      string        `json:"details"`
+}
+
+type auditLogPage struct {
+	Entries    []auditEntryReturn `json:"entries"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func toReportReturn(r database.Report) reportReturn {
+	return reportReturn{
+		Id:            r.ID,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+		ChirpID:       r.ChirpID,
+		ChirpAuthorID: r.ChirpAuthorID,
+		ReporterID:    r.ReporterID,
+		Reason:        r.Reason,
+		Details:       r.Details,
+		Status:        r.Status,
+		ClaimedBy:     r.ClaimedBy,
+		ClaimedAt:     nullTimePtr(r.ClaimedAt),
+		ResolvedBy:    r.ResolvedBy,
+		ResolvedAt:    nullTimePtr(r.ResolvedAt),
+		Resolution:    nullStringPtr(r.Resolution),
+	}
+}
+
+// audit records a moderator's action. It takes the transaction's queries so
+// the entry is only kept if the action itself is.
+func audit(ctx context.Context, q *database.Queries, entry database.CreateAuditLogEntryParams) error {
+	if err := q.CreateAuditLogEntry(ctx, entry); err != nil {
+		return fmt.Errorf("couldn't write audit log: %s", err)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) reportChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	params := reportParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	if !reportReasons[params.Reason] {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "unknown reason"})
+		return
+	}
+	params.Details = strings.TrimSpace(params.Details)
+	if len([]rune(params.Details)) > maxReportDetailsLength {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("details must be at most %d characters", maxReportDetailsLength)})
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "chirp not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	if chirp.UserID.UUID == userID {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "you can't report your own chirp"})
+		return
+	}
+
+	// one open report per person per chirp; reporting again is a no-op
+	// that comes back with no row
+	report, err := cfg.dbQueries.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+		ChirpID:    chirpId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "you have already reported this chirp"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't create report: %s", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, toReportReturn(report))
+}
+
+func (cfg *apiConfig) listReports(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = reportStatusOpen
+	}
+	if status != reportStatusOpen && status != reportStatusClaimed && status != reportStatusResolved {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "status must be open, claimed or resolved"})
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	listParams := database.ListReportsParams{
+		Status:    status,
+		PageLimit: int32(limit + 1),
+	}
+	if reason := r.URL.Query().Get("reason"); reason != "" {
+		listParams.Reason = sql.NullString{String: reason, Valid: true}
+	}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	// the queue is worked oldest first
+	rows, err := cfg.dbQueries.ListReports(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	reports := make([]reportReturn, 0, len(rows))
+	for _, v := range rows {
+		reports = append(reports, toReportReturn(v))
+	}
+	respondWithJSON(w, http.StatusOK, reportsPage{Reports: reports, NextCursor: nextCursor})
+}
+
+// lockReport loads a report for a moderator to act on, inside tx. It fails
+// with 409 when the report is resolved or someone else has claimed it.
+func lockReport(ctx context.Context, q *database.Queries, reportID, moderatorID uuid.UUID) (database.Report, int, error) {
+	report, err := q.GetReportForUpdate(ctx, reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, http.StatusNotFound, errors.New("report not found")
+	} else if err != nil {
+		return report, http.StatusInternalServerError, fmt.Errorf("couldn't load report: %s", err)
+	}
+
+	switch {
+	case report.Status == reportStatusResolved:
+		return report, http.StatusConflict, errors.New("report is already resolved")
+	case report.Status == reportStatusClaimed && report.ClaimedBy.UUID != moderatorID:
+		return report, http.StatusConflict, errors.New("report is claimed by another moderator")
+	}
+	return report, http.StatusOK, nil
+}
+
+func (cfg *apiConfig) claimReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID := callerID(r)
+
+	reportID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid report id"})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	report, status, err := lockReport(r.Context(), qtx, reportID, moderatorID)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		respondWithJSON(w, status, errorReturn{Error: err.Error()})
+		return
+	}
+
+	// claiming a report you already hold changes nothing
+	if report.Status == reportStatusClaimed {
+		respondWithJSON(w, http.StatusOK, toReportReturn(report))
+		return
+	}
+
+	report, err = qtx.ClaimReport(r.Context(), database.ClaimReportParams{
+		ModeratorID: moderatorID,
+		ID:          reportID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't claim report: %s", err))
+		return
+	}
+
+	if err := audit(r.Context(), qtx, database.CreateAuditLogEntryParams{
+		ActorID:  uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:   "claim_report",
+		ReportID: uuid.NullUUID{UUID: reportID, Valid: true},
+		ChirpID:  report.ChirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit claim: %s", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toReportReturn(report))
+}
+
+func (cfg *apiConfig) resolveReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID := callerID(r)
+
+	reportID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid report id"})
+		return
+	}
+
+	params := resolveParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	switch params.Action {
+	case resolutionDismiss, resolutionDeleteChirp, resolutionSuspendAuthor:
+	default:
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "action must be dismiss, delete_chirp or suspend_author"})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// an open report can be resolved straight away without claiming it first
+	report, status, err := lockReport(r.Context(), qtx, reportID, moderatorID)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		respondWithJSON(w, status, errorReturn{Error: err.Error()})
+		return
+	}
+
+	if params.Action == resolutionSuspendAuthor {
+		if !report.ChirpAuthorID.Valid {
+			respondWithJSON(w, http.StatusConflict, errorReturn{Error: "the author's account no longer exists"})
+			return
+		}
+		author, err := qtx.GetUserByID(r.Context(), report.ChirpAuthorID.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load author: %s", err))
+			return
+		}
+		// the same rule as suspending someone directly, so a report can't be
+		// used to lock out a moderator or admin
+		if auth.Role(author.Role).IsStaff() {
+			respondWithJSON(w, http.StatusConflict, errorReturn{Error: "moderators and admins can't be suspended; take their role away first"})
+			return
+		}
+	}
+
+	resolved, err := qtx.ResolveReport(r.Context(), database.ResolveReportParams{
+		Resolution:  params.Action,
+		ModeratorID: moderatorID,
+		ID:          reportID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't resolve report: %s", err))
+		return
+	}
+
+	entry := database.CreateAuditLogEntryParams{
+		ActorID:  uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:   params.Action,
+		ReportID: uuid.NullUUID{UUID: reportID, Valid: true},
+		ChirpID:  report.ChirpID,
+		Details:  strings.TrimSpace(params.Note),
+	}
+
+	switch params.Action {
+	case resolutionDeleteChirp:
+		// the chirp may already be gone, in which case there is nothing left
+		// to do but close the report
+		if report.ChirpID.Valid {
+			// every other report about the chirp is settled by deleting it
+			if err := qtx.ResolveOpenReportsForChirp(r.Context(), database.ResolveOpenReportsForChirpParams{
+				Resolution:  params.Action,
+				ModeratorID: moderatorID,
+				ChirpID:     report.ChirpID.UUID,
+			}); err != nil {
+				respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't resolve other reports: %s", err))
+				return
+			}
+			if err := qtx.DeleteChirp(r.Context(), report.ChirpID.UUID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't delete chirp: %s", err))
+				return
+			}
+		}
+		entry.TargetUserID = report.ChirpAuthorID
+	case resolutionSuspendAuthor:
+		if _, err := suspendAccount(r.Context(), qtx, report.ChirpAuthorID.UUID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		entry.TargetUserID = report.ChirpAuthorID
+	}
+
+	if err := audit(r.Context(), qtx, entry); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit resolution: %s", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toReportReturn(resolved))
+}
+
+func (cfg *apiConfig) listAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	listParams := database.ListAuditLogParams{PageLimit: int32(limit + 1)}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+			return
+		}
+		listParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		listParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.ListAuditLog(r.Context(), listParams)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, nextCursor, limit)
+	}
+
+	entries := make([]auditEntryReturn, 0, len(rows))
+	for _, v := range rows {
+		entries = append(entries, auditEntryReturn{
+			Id:           v.ID,
+			CreatedAt:    v.CreatedAt,
+			ActorID:      v.ActorID,
+			Action:       v.Action,
+			ReportID:     v.ReportID,
+			ChirpID:      v.ChirpID,
+			TargetUserID: v.TargetUserID,
+			Details:      v.Details,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, auditLogPage{Entries: entries, NextCursor: nextCursor})
+}
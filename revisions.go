@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+type UpdateChirpParams struct {
+	Body string `json:"body"`
+}
+
+type revisionReturn struct {
+	Id        uuid.UUID `json:"id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) updateChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	params := UpdateChirpParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	cleanedBody, flagged, err := cfg.validateChirpBody(params.Body)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// lock the row so two concurrent edits can't both record the same body as
+	// the previous revision
+	if chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpId); err != nil {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "query failed"})
+		return
+	} else if chirp.UserID.UUID != userID {
+		respondWithError(w, http.StatusForbidden, fmt.Errorf("you are not allowed to edit this chirp"))
+		return
+	} else if chirp.RechirpOf.Valid {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "rechirps can't be edited"})
+		return
+	}
+
+	if err := qtx.CreateChirpRevision(r.Context(), chirpId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't save chirp revision: %s", err))
+		return
+	}
+
+	chirp, err := qtx.UpdateChirp(r.Context(), database.UpdateChirpParams{
+		Body: cleanedBody,
+		ID:   chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't update chirp: %s", err))
+		return
+	}
+
+	if err := indexChirp(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := flagChirp(r.Context(), qtx, chirp.ID, flagged); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit chirp update: %s", err))
+		return
+	}
+
+	returnChirp, err := cfg.chirpToReturn(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnChirp)
+}
+
+func (cfg *apiConfig) getChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpId,
+		ViewerID: cfg.optionalUserID(r),
+	}); errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "chirp not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), chirpId)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	resp := make([]revisionReturn, 0, len(revisions))
+	for _, v := range revisions {
+		resp = append(resp, revisionReturn{
+			Id:        v.ID,
+			ChirpID:   v.ChirpID,
+			CreatedAt: v.CreatedAt,
+			Body:      v.Body,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
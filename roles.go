@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+type callerKey struct{}
+
+// requireRole only lets requests through to next when the caller holds at
+// least role. next can get the caller's id from callerID.
+func (cfg *apiConfig) requireRole(role auth.Role, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithAuthError(w, http.StatusUnauthorized, err)
+			return
+		}
+		claims, err := cfg.tokens.Parse(token)
+		if err != nil {
+			respondWithAuthError(w, http.StatusUnauthorized, err)
+			return
+		}
+		// the claim turns most callers away without a database round trip
+		if !claims.Role.Includes(role) {
+			respondWithAuthError(w, http.StatusForbidden, fmt.Errorf("%w: user %s is not a %s", auth.ErrInsufficientRole, claims.Subject, role))
+			return
+		}
+
+		// but the stored role has the final say, so taking a role away works
+		// before the caller's token runs out
+		user, ok := cfg.authenticatedUser(w, r)
+		if !ok {
+			return
+		}
+		if !auth.Role(user.Role).Includes(role) {
+			respondWithAuthError(w, http.StatusForbidden, fmt.Errorf("%w: user %s is no longer a %s", auth.ErrInsufficientRole, user.ID, role))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, user.ID)))
+	})
+}
+
+// callerID returns the id of the user requireRole let through.
+func callerID(r *http.Request) uuid.UUID {
+	id, _ := r.Context().Value(callerKey{}).(uuid.UUID)
+	return id
+}
+
+// runCommand runs one of the maintenance commands given on the command line
+// instead of starting the server.
+func runCommand(ctx context.Context, db *sql.DB, args []string) error {
+	switch args[0] {
+	case "grant-role":
+		if len(args) != 3 {
+			return errors.New("usage: chirpy grant-role <email> <user|moderator|admin>")
+		}
+		return grantRole(ctx, db, args[1], args[2])
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// grantRole gives an existing user a role, replacing the one they had. It is
+// how the first admin gets made, so it needs no one signed in, and the audit
+// log records it with no actor.
+func grantRole(ctx context.Context, db *sql.DB, email, rawRole string) error {
+	role, err := auth.ParseRole(rawRole)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %s", err)
+	}
+	defer tx.Rollback()
+	qtx := database.New(tx)
+
+	user, err := qtx.SetUserRoleByEmail(ctx, database.SetUserRoleByEmailParams{
+		Role:  string(role),
+		Email: email,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no user has the email %s", email)
+	} else if err != nil {
+		return fmt.Errorf("couldn't set role: %s", err)
+	}
+
+	if err := audit(ctx, qtx, database.CreateAuditLogEntryParams{
+		Action:       "grant_role",
+		TargetUserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Details:      string(role),
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit role: %s", err)
+	}
+
+	fmt.Printf("%s is now a %s\n", email, role)
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/search"
+)
+
+const (
+	// maxSearchOffset stops clients paging arbitrarily deep into search
+	// results, since every page has to rank all the rows before it again.
+	maxSearchOffset = 1000
+	// maxUserSearchResults keeps typeahead responses small; nobody scrolls
+	// through more suggestions than this
+	maxUserSearchResults = 20
+	maxUserQueryLength   = 50
+)
+
+type searchHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type searchResult struct {
+	returnVals
+	Rank       float64           `json:"rank"`
+	Snippet    string            `json:"snippet"`
+	Highlights []searchHighlight `json:"highlights"`
+}
+
+type searchPage struct {
+	Chirps     []searchResult `json:"chirps"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type userSearchResult struct {
+	Id           uuid.UUID `json:"id"`
+	Handle       string    `json:"handle"`
+	DisplayName  string    `json:"display_name"`
+	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	FollowedByMe *bool     `json:"followed_by_me,omitempty"`
+}
+
+type userSearchReturn struct {
+	Users []userSearchResult `json:"users"`
+}
+
+// indexChirp refreshes everything derived from a chirp's body. Callers run it
+// in the transaction that saves the chirp, so the indexes can't fall behind.
+// The search document is a generated column, so Postgres keeps that one.
+func indexChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	return indexChirpEntities(ctx, q, chirp)
+}
+
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+	query, err := search.ToTSQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+
+	offset := 0
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		offset, err = decodeOffsetCursor(rawCursor)
+		if err != nil || offset > maxSearchOffset {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid cursor"})
+			return
+		}
+	}
+
+	viewerID := cfg.optionalUserID(r)
+	rows, err := cfg.dbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:      query,
+		ViewerID:   viewerID,
+		PageLimit:  int32(limit + 1),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	// results are ranked rather than ordered by a column, so pages are
+	// offsets; a chirp posted mid-browse can shift later pages by a result
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		if offset+limit <= maxSearchOffset {
+			nextCursor = encodeOffsetCursor(offset + limit)
+			setNextLink(w, r, nextCursor, limit)
+		}
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, v := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			Body:      v.Body,
+			UserID:    v.UserID,
+			InReplyTo: v.InReplyTo,
+			RechirpOf: v.RechirpOf,
+			QuoteOf:   v.QuoteOf,
+		})
+	}
+
+	hydrated, err := cfg.chirpsToReturn(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	results := make([]searchResult, 0, len(rows))
+	for i, v := range rows {
+		snippet, spans := search.Highlights(v.Snippet)
+		highlights := make([]searchHighlight, 0, len(spans))
+		for _, span := range spans {
+			highlights = append(highlights, searchHighlight(span))
+		}
+		results = append(results, searchResult{
+			returnVals: hydrated[i],
+			Rank:       v.Rank,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, searchPage{Chirps: results, NextCursor: nextCursor})
+}
+
+// likePrefix turns s into a LIKE pattern matching anything that starts with it.
+func likePrefix(s string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return escaped + "%"
+}
+
+func (cfg *apiConfig) searchUsers(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	// the compose box sends what follows the @, but people paste it too
+	query = strings.TrimPrefix(query, "@")
+	if query == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "q is required"})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxUserQueryLength {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("q must be at most %d characters", maxUserQueryLength)})
+		return
+	}
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: err.Error()})
+		return
+	}
+	limit = min(limit, maxUserSearchResults)
+
+	viewerID := cfg.optionalUserID(r)
+
+	// an exact handle comes first, then people the viewer follows, then
+	// whatever is closest to what was typed
+	rows, err := cfg.dbQueries.SearchUsers(r.Context(), database.SearchUsersParams{
+		Query:         query,
+		ViewerID:      viewerID,
+		PrefixPattern: likePrefix(query),
+		ResultLimit:   int32(limit),
+	})
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	users := make([]userSearchResult, 0, len(rows))
+	for _, v := range rows {
+		user := userSearchResult{
+			Id:          v.ID,
+			Handle:      v.Handle,
+			DisplayName: v.DisplayName,
+			IsChirpyRed: v.IsChirpyRed,
+		}
+		if viewerID.Valid {
+			followed := v.FollowedByMe
+			user.FollowedByMe = &followed
+		}
+		users = append(users, user)
+	}
+
+	respondWithJSON(w, http.StatusOK, userSearchReturn{Users: users})
+}
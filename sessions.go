@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+// maxUserAgentLength stops a client filling the sessions table with an
+// arbitrarily long header.
+const maxUserAgentLength = 512
+
+type sessionReturn struct {
+	Id         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+// clientDevice describes what r came from, as stored against a session. The
+// IP is the address the connection came from; X-Forwarded-For is anyone's to
+// set, so it isn't trusted.
+func clientDevice(r *http.Request) (userAgent, ip string) {
+	userAgent = r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return userAgent, ip
+}
+
+// startSession signs the user in on the device r came from, returning the new
+// session's id and its first refresh token.
+func (cfg *apiConfig) startSession(r *http.Request, userID uuid.UUID) (uuid.UUID, string, error) {
+	userAgent, ip := clientDevice(r)
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	session, err := qtx.CreateSession(r.Context(), database.CreateSessionParams{
+		UserID:    userID,
+		UserAgent: userAgent,
+		Ip:        ip,
+	})
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("couldn't create session: %s", err)
+	}
+
+	refreshToken, err := issueRefreshToken(r.Context(), qtx, userID, session.ID)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("couldn't issue refresh token: %s", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, "", err
+	}
+	return session.ID, refreshToken, nil
+}
+
+// errSessionEnded is returned by continueSession when the caller's session
+// has been signed out, or their access token doesn't name one.
+var errSessionEnded = errors.New("session has been signed out")
+
+// continueSession hands the session r's access token was issued to a new
+// refresh token, retiring the ones it had, so a client given fresh tokens
+// mid-session stays one device. q has to be a transaction.
+func (cfg *apiConfig) continueSession(r *http.Request, q *database.Queries, userID uuid.UUID) (uuid.UUID, string, error) {
+	current := cfg.currentSession(r)
+	if !current.Valid {
+		return uuid.Nil, "", errSessionEnded
+	}
+
+	rotated, err := q.RotateSession(r.Context(), database.RotateSessionParams{
+		FamilyID: current.UUID,
+		UserID:   uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("couldn't rotate session: %s", err)
+	}
+	if rotated == 0 {
+		return uuid.Nil, "", errSessionEnded
+	}
+
+	userAgent, ip := clientDevice(r)
+	if err := q.TouchSession(r.Context(), database.TouchSessionParams{
+		ID:        current.UUID,
+		UserAgent: userAgent,
+		Ip:        ip,
+	}); err != nil {
+		return uuid.Nil, "", fmt.Errorf("couldn't update session: %s", err)
+	}
+
+	refreshToken, err := issueRefreshToken(r.Context(), q, userID, current.UUID)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("couldn't issue refresh token: %s", err)
+	}
+	return current.UUID, refreshToken, nil
+}
+
+// currentSession returns the session the caller's access token was issued to,
+// if it names one. Callers must have authenticated the request already.
+func (cfg *apiConfig) currentSession(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	claims, err := cfg.tokens.Parse(token)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return claims.Session()
+}
+
+func (cfg *apiConfig) listSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.dbQueries.ListActiveSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't list sessions: %s", err))
+		return
+	}
+
+	current := cfg.currentSession(r)
+	sessions := make([]sessionReturn, 0, len(rows))
+	for _, v := range rows {
+		sessions = append(sessions, sessionReturn{
+			Id:         v.ID,
+			CreatedAt:  v.CreatedAt,
+			LastUsedAt: v.LastUsedAt,
+			UserAgent:  v.UserAgent,
+			IP:         v.Ip,
+			Current:    current.Valid && current.UUID == v.ID,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// deleteSession signs one of the caller's devices out. Access tokens it
+// already holds keep working until they expire.
+func (cfg *apiConfig) deleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid session id"})
+		return
+	}
+
+	revoked, err := cfg.dbQueries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't revoke session: %s", err))
+		return
+	}
+	if revoked == 0 {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "session not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessions signs the caller out everywhere, including the device
+// making the request.
+func (cfg *apiConfig) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.dbQueries.RevokeUserRefreshTokens(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't revoke sessions: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeOtherSessions signs the user out of every session but the one the
+// request came from, or all of them if the request isn't from a session.
+func (cfg *apiConfig) revokeOtherSessions(r *http.Request, q *database.Queries, userID uuid.UUID) error {
+	user := uuid.NullUUID{UUID: userID, Valid: true}
+
+	current := cfg.currentSession(r)
+	if !current.Valid {
+		return q.RevokeUserRefreshTokens(r.Context(), user)
+	}
+	return q.RevokeOtherSessions(r.Context(), database.RevokeOtherSessionsParams{
+		UserID:   user,
+		FamilyID: current.UUID,
+	})
+}
@@ -0,0 +1,173 @@
+-- name: ListChirpsAsc :many
+SELECT *
+FROM chirps
+WHERE (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+    AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+    AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+    )
+    AND (
+        user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+-- name: ListChirpsDesc :many
+SELECT *
+FROM chirps
+WHERE (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+    AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+    AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+    )
+    AND (
+        user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+-- name: GetVisibleChirp :one
+SELECT *
+FROM chirps
+WHERE id = sqlc.arg(id)::uuid
+    AND (
+        user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    );
+-- name: GetChirpForUpdate :one
+SELECT *
+FROM chirps
+WHERE id = $1
+FOR UPDATE;
+-- name: UpdateChirp :one
+UPDATE chirps
+SET
+    body = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, created_at, body)
+SELECT gen_random_uuid(), id, updated_at, body
+FROM chirps
+WHERE id = $1;
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at ASC;
+-- name: CountRepliesForChirps :many
+SELECT in_reply_to::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY in_reply_to;
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.*, 1 AS depth
+    FROM chirps parent
+    WHERE parent.id = (
+        SELECT child.in_reply_to
+        FROM chirps child
+        WHERE child.id = sqlc.arg(chirp_id)::uuid
+    )
+    UNION ALL
+    SELECT c.*, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, rechirp_of, quote_of
+FROM ancestors
+WHERE user_id = sqlc.narg(viewer_id)::uuid
+    OR NOT EXISTS (
+        SELECT 1
+        FROM users
+        WHERE users.id = ancestors.user_id
+            AND users.shadow_banned_at IS NOT NULL
+    )
+ORDER BY depth DESC;
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT c.*, 1 AS depth
+    FROM chirps c
+    WHERE c.in_reply_to = sqlc.arg(chirp_id)::uuid
+    AND (
+        c.user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = c.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+    UNION ALL
+    SELECT c.*, d.depth + 1
+    FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+    WHERE d.depth < sqlc.arg(max_depth)::int
+    AND (
+        c.user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = c.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, rechirp_of, quote_of, depth
+FROM descendants
+ORDER BY depth, created_at, id
+LIMIT sqlc.arg(max_replies);
+-- name: GetChirpsByIDs :many
+SELECT *
+FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+    AND (
+        user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    );
+-- name: CreateRechirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+        gen_random_uuid(),
+        NOW(),
+        NOW(),
+        '',
+        sqlc.arg(user_id)::uuid,
+        sqlc.arg(rechirp_of)::uuid
+    )
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING *;
+-- name: GetRechirp :one
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg(user_id)::uuid
+    AND rechirp_of = sqlc.arg(rechirp_of)::uuid;
+-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = sqlc.arg(user_id)::uuid
+    AND rechirp_of = sqlc.arg(rechirp_of)::uuid;
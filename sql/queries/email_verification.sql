@@ -0,0 +1,22 @@
+-- name: CreateEmailVerification :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+VALUES ($1, $2, $3, NOW(), $4);
+-- name: UseEmailVerification :one
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > NOW()
+RETURNING user_id, email;
+-- name: ExpireEmailVerifications :exec
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE user_id = $1
+    AND used_at IS NULL;
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, NOW()),
+    updated_at = NOW()
+WHERE id = $1
+    AND email = $2
+RETURNING email_verified_at;
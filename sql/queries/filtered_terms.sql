@@ -0,0 +1,13 @@
+-- name: ListFilteredTerms :many
+SELECT *
+FROM filtered_terms
+ORDER BY term;
+-- name: UpsertFilteredTerm :one
+INSERT INTO filtered_terms (term, created_at, action)
+VALUES ($1, NOW(), $2)
+ON CONFLICT (term) DO UPDATE
+SET action = EXCLUDED.action
+RETURNING *;
+-- name: DeleteFilteredTerm :execrows
+DELETE FROM filtered_terms
+WHERE term = $1;
@@ -0,0 +1,68 @@
+-- name: FollowUser :exec
+INSERT INTO follows(follower_id, followee_id, created_at)
+VALUES (
+        $1,
+        $2,
+        NOW()
+    )
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1
+    AND followee_id = $2;
+-- name: CountFollowers :one
+SELECT COUNT(*)
+FROM follows
+WHERE followee_id = $1;
+-- name: CountFollowing :one
+SELECT COUNT(*)
+FROM follows
+WHERE follower_id = $1;
+-- name: ListFollowers :many
+SELECT follower_id AS user_id, created_at AS followed_at
+FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(cursor_followed_at)::timestamp IS NULL
+        OR (created_at, follower_id) < (sqlc.narg(cursor_followed_at)::timestamp, sqlc.narg(cursor_user_id)::uuid)
+    )
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(page_limit);
+-- name: ListFollowing :many
+SELECT followee_id AS user_id, created_at AS followed_at
+FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(cursor_followed_at)::timestamp IS NULL
+        OR (created_at, followee_id) < (sqlc.narg(cursor_followed_at)::timestamp, sqlc.narg(cursor_user_id)::uuid)
+    )
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(page_limit);
+-- name: ListTimeline :many
+SELECT c.*
+FROM (
+        SELECT sqlc.arg(user_id)::uuid AS author_id
+        UNION
+        SELECT f.followee_id
+        FROM follows f
+        WHERE f.follower_id = sqlc.arg(user_id)::uuid
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users
+                WHERE users.id = f.followee_id
+                    AND users.shadow_banned_at IS NOT NULL
+            )
+    ) authors
+    CROSS JOIN LATERAL (
+        SELECT *
+        FROM chirps
+        WHERE chirps.user_id = authors.author_id
+            AND (
+                sqlc.narg(cursor_created_at)::timestamp IS NULL
+                OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+            )
+        ORDER BY created_at DESC, id DESC
+        LIMIT sqlc.arg(page_limit)
+    ) c
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,62 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags(id, tag, created_at)
+VALUES (
+        gen_random_uuid(),
+        $1,
+        NOW()
+    )
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags(chirp_id, hashtag_id, created_at)
+SELECT id, sqlc.arg(hashtag_id)::uuid, created_at
+FROM chirps
+WHERE id = sqlc.arg(chirp_id)::uuid
+ON CONFLICT (chirp_id, hashtag_id) DO NOTHING;
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+-- name: ListChirpsByHashtag :many
+SELECT *
+FROM chirps
+WHERE id IN (
+        SELECT ch.chirp_id
+        FROM chirp_hashtags ch
+        JOIN hashtags h ON h.id = ch.hashtag_id
+        WHERE h.tag = sqlc.arg(tag)
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+    )
+    AND (
+        user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+-- name: ComputeTrendingHashtags :many
+SELECT
+    h.tag,
+    SUM(
+        EXP(-LN(2) * EXTRACT(EPOCH FROM (NOW()::timestamp - ch.created_at)) / sqlc.arg(half_life_seconds)::float8)
+    )::float8 AS score,
+    COUNT(*) AS uses
+FROM chirp_hashtags ch
+JOIN hashtags h ON h.id = ch.hashtag_id
+JOIN chirps c ON c.id = ch.chirp_id
+WHERE ch.created_at > NOW()::timestamp - make_interval(secs => sqlc.arg(window_seconds)::float8)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM users
+        WHERE users.id = c.user_id
+            AND users.shadow_banned_at IS NOT NULL
+    )
+GROUP BY h.tag
+ORDER BY score DESC, h.tag
+LIMIT sqlc.arg(max_tags);
@@ -0,0 +1,42 @@
+-- name: LikeChirp :exec
+INSERT INTO chirp_likes(user_id, chirp_id, created_at)
+VALUES (
+        $1,
+        $2,
+        NOW()
+    )
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE user_id = $1
+    AND chirp_id = $2;
+-- name: CountLikesForChirps :many
+SELECT chirp_id, COUNT(*) AS like_count
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id
+FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+    AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+-- name: ListLikedChirps :many
+SELECT c.*, l.created_at AS liked_at
+FROM chirp_likes l
+JOIN chirps c ON c.id = l.chirp_id
+WHERE l.user_id = sqlc.arg(user_id)
+    AND (
+        c.user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = c.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+    AND (
+        sqlc.narg(cursor_liked_at)::timestamp IS NULL
+        OR (l.created_at, l.chirp_id) < (sqlc.narg(cursor_liked_at)::timestamp, sqlc.narg(cursor_chirp_id)::uuid)
+    )
+ORDER BY l.created_at DESC, l.chirp_id DESC
+LIMIT sqlc.arg(page_limit);
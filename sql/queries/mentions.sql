@@ -0,0 +1,45 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle
+FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg(handles)::text[]);
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions(chirp_id, user_id, created_at, handle, start_offset, end_offset)
+SELECT id,
+    sqlc.arg(user_id)::uuid,
+    created_at,
+    sqlc.arg(handle)::text,
+    sqlc.arg(start_offset)::int,
+    sqlc.arg(end_offset)::int
+FROM chirps
+WHERE id = sqlc.arg(chirp_id)::uuid;
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+-- name: GetMentionsForChirps :many
+SELECT chirp_id, user_id, handle, start_offset, end_offset
+FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, start_offset;
+-- name: ListMentioningChirps :many
+SELECT *
+FROM chirps
+WHERE id IN (
+        SELECT chirp_id
+        FROM chirp_mentions
+        WHERE chirp_mentions.user_id = sqlc.arg(user_id)::uuid
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+    )
+    AND (
+        chirps.user_id = sqlc.arg(user_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = chirps.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,128 @@
+-- name: FlagChirp :exec
+INSERT INTO reports (id, created_at, updated_at, chirp_id, chirp_author_id, reason, details)
+SELECT gen_random_uuid(),
+    NOW(),
+    NOW(),
+    c.id,
+    c.user_id,
+    'filtered_term',
+    sqlc.arg(term)::text
+FROM chirps c
+WHERE c.id = sqlc.arg(chirp_id)::uuid
+    AND NOT EXISTS (
+        SELECT 1
+        FROM reports r
+        WHERE r.chirp_id = c.id
+            AND r.reason = 'filtered_term'
+            AND r.details = sqlc.arg(term)::text
+            AND r.status <> 'resolved'
+    );
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, chirp_id, chirp_author_id, reporter_id, reason, details)
+SELECT gen_random_uuid(),
+    NOW(),
+    NOW(),
+    c.id,
+    c.user_id,
+    sqlc.arg(reporter_id)::uuid,
+    sqlc.arg(reason)::text,
+    sqlc.arg(details)::text
+FROM chirps c
+WHERE c.id = sqlc.arg(chirp_id)::uuid
+ON CONFLICT (reporter_id, chirp_id) WHERE status <> 'resolved' DO NOTHING
+RETURNING *;
+-- name: GetReportForUpdate :one
+SELECT *
+FROM reports
+WHERE id = $1
+FOR UPDATE;
+-- name: ListReports :many
+SELECT *
+FROM reports
+WHERE status = sqlc.arg(status)::text
+    AND (
+        sqlc.narg(reason)::text IS NULL
+        OR reason = sqlc.narg(reason)::text
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY created_at,
+    id
+LIMIT sqlc.arg(page_limit);
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed',
+    claimed_by = sqlc.arg(moderator_id)::uuid,
+    claimed_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved',
+    resolution = sqlc.arg(resolution)::text,
+    resolved_by = sqlc.arg(moderator_id)::uuid,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
+-- name: ResolveOpenReportsForChirp :exec
+UPDATE reports
+SET status = 'resolved',
+    resolution = sqlc.arg(resolution)::text,
+    resolved_by = sqlc.arg(moderator_id)::uuid,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE chirp_id = sqlc.arg(chirp_id)::uuid
+    AND status <> 'resolved';
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, NOW()),
+    updated_at = NOW()
+WHERE id = $1;
+-- name: UnsuspendUser :execrows
+UPDATE users
+SET suspended_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: ShadowBanUser :execrows
+UPDATE users
+SET shadow_banned_at = COALESCE(shadow_banned_at, NOW()),
+    updated_at = NOW()
+WHERE id = $1;
+-- name: UnshadowBanUser :execrows
+UPDATE users
+SET shadow_banned_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: CreateAuditLogEntry :exec
+INSERT INTO moderation_audit_log (
+        id,
+        created_at,
+        actor_id,
+        action,
+        report_id,
+        chirp_id,
+        target_user_id,
+        details
+    )
+VALUES (
+        gen_random_uuid(),
+        NOW(),
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6
+    );
+-- name: ListAuditLog :many
+SELECT *
+FROM moderation_audit_log
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+ORDER BY created_at DESC,
+    id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,20 @@
+-- name: CreatePasswordReset :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES ($1, $2, NOW(), $3);
+-- name: UsePasswordReset :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+    AND used_at IS NULL
+    AND expires_at > NOW()
+RETURNING user_id;
+-- name: ExpirePasswordResets :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1
+    AND used_at IS NULL;
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $1,
+    updated_at = NOW()
+WHERE id = $2;
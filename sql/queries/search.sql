@@ -0,0 +1,32 @@
+-- name: SearchChirps :many
+SELECT c.id,
+    c.created_at,
+    c.updated_at,
+    c.body,
+    c.user_id,
+    c.in_reply_to,
+    c.rechirp_of,
+    c.quote_of,
+    ts_rank_cd(to_tsvector('english', c.body), q)::float8 AS rank,
+    ts_headline(
+        'english',
+        translate(c.body, chr(2) || chr(3), ''),
+        q,
+        'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MinWords=5, MaxWords=20'
+    )::text AS snippet
+FROM chirps c
+    CROSS JOIN to_tsquery('english', sqlc.arg(query)::text) AS q
+WHERE to_tsvector('english', c.body) @@ q
+    AND (
+        c.user_id = sqlc.narg(viewer_id)::uuid
+        OR NOT EXISTS (
+            SELECT 1
+            FROM users
+            WHERE users.id = c.user_id
+                AND users.shadow_banned_at IS NOT NULL
+        )
+    )
+ORDER BY rank DESC,
+    c.created_at DESC,
+    c.id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
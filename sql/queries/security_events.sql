@@ -0,0 +1,3 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (id, created_at, user_id, kind, details)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3);
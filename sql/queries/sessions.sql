@@ -0,0 +1,46 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, created_at, last_used_at, user_agent, ip)
+VALUES (gen_random_uuid(), $1, NOW(), NOW(), $2, $3)
+RETURNING *;
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_used_at = NOW(),
+    user_agent = $2,
+    ip = $3
+WHERE id = $1;
+-- name: ListActiveSessions :many
+SELECT *
+FROM sessions
+WHERE user_id = $1
+    AND EXISTS (
+        SELECT 1
+        FROM refresh_tokens
+        WHERE refresh_tokens.family_id = sessions.id
+            AND refresh_tokens.rotated_at IS NULL
+            AND refresh_tokens.revoked_at IS NULL
+            AND refresh_tokens.expires_at > NOW()
+    )
+ORDER BY last_used_at DESC;
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1
+    AND user_id = $2
+    AND revoked_at IS NULL;
+-- name: RotateSession :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1
+    AND user_id = $2
+    AND rotated_at IS NULL
+    AND revoked_at IS NULL
+    AND expires_at > NOW();
+-- name: RevokeOtherSessions :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND family_id <> $2
+    AND revoked_at IS NULL;
@@ -0,0 +1,10 @@
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (id, encrypted_private_key, created_at)
+VALUES ($1, $2, $3);
+-- name: ListSigningKeys :many
+SELECT *
+FROM signing_keys
+ORDER BY created_at;
+-- name: DeleteSigningKeysBefore :exec
+DELETE FROM signing_keys
+WHERE created_at < $1;
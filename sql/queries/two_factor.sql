@@ -0,0 +1,64 @@
+-- name: StartTOTPEnrollment :exec
+INSERT INTO totp_credentials (user_id, secret, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret,
+    created_at = EXCLUDED.created_at,
+    enabled_at = NULL,
+    last_step = 0,
+    failed_attempts = 0,
+    last_failed_at = NULL;
+-- name: GetTOTPCredential :one
+SELECT *
+FROM totp_credentials
+WHERE user_id = $1;
+-- name: GetTOTPCredentialForUpdate :one
+SELECT *
+FROM totp_credentials
+WHERE user_id = $1
+FOR UPDATE;
+-- name: EnableTOTP :exec
+UPDATE totp_credentials
+SET enabled_at = NOW(),
+    last_step = $1
+WHERE user_id = $2;
+-- name: SetTOTPLastStep :exec
+UPDATE totp_credentials
+SET last_step = $1
+WHERE user_id = $2;
+-- name: RecordTOTPFailure :exec
+UPDATE totp_credentials
+SET failed_attempts = CASE
+        WHEN last_failed_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::float8) THEN failed_attempts + 1
+        ELSE 1
+    END,
+    last_failed_at = NOW()
+WHERE user_id = sqlc.arg(user_id);
+-- name: ResetTOTPFailures :exec
+UPDATE totp_credentials
+SET failed_attempts = 0,
+    last_failed_at = NULL
+WHERE user_id = $1;
+-- name: IsTOTPLockedOut :one
+SELECT COALESCE(
+        failed_attempts >= sqlc.arg(max_attempts)::int
+        AND last_failed_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::float8),
+        false
+    )::bool AS locked_out
+FROM totp_credentials
+WHERE user_id = sqlc.arg(user_id);
+-- name: DeleteTOTPCredential :exec
+DELETE FROM totp_credentials
+WHERE user_id = $1;
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (code_hash, user_id, created_at)
+VALUES ($1, $2, NOW());
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE code_hash = $1
+    AND user_id = $2
+    AND used_at IS NULL;
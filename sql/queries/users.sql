@@ -4,14 +4,22 @@ INSERT INTO users (
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle,
+        display_name,
+        bio,
+        location
     )
 VALUES (
         gen_random_uuid(),
         NOW(),
         NOW(),
         $1,
-        $2
+        $2,
+        $3,
+        $4,
+        $5,
+        $6
     )
 RETURNING *;
 -- name: Reset :exec
@@ -22,19 +30,17 @@ FROM users
 WHERE email = $1
 LIMIT 1;
 -- name: CreateChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES (
         gen_random_uuid(),
         NOW(),
         NOW(),
         $1,
-        $2
+        $2,
+        $3,
+        $4
     )
 RETURNING *;
--- name: GetAllChirps :many
-SELECT *
-FROM chirps
-ORDER BY created_at ASC;
 -- name: GetChirp :one
 SELECT *
 FROM chirps
@@ -44,27 +50,46 @@ SELECT *
 FROM users
 WHERE email = $1;
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens(token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens(token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
 VALUES (
     $1,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
-
+    NULL,
+    $4
 )
 RETURNING *;
 -- name: GetRefreshToken :one
 SELECT * 
 FROM refresh_tokens
-WHERE token = $1;
+WHERE token_hash = $1;
 -- name: RevokeRefreshToken :exec
 UPDAte refresh_tokens
 SET
     revoked_at = NOW(),
     updated_at = NOW()
-WHERE token = $1;
+WHERE token_hash = $1;
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND revoked_at IS NULL;
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW(),
+    updated_at = NOW()
+WHERE token_hash = $1
+    AND rotated_at IS NULL
+    AND revoked_at IS NULL;
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1
+    AND revoked_at IS NULL;
 -- name: GetUserByID :one
 SELECT *
 FROM users
@@ -74,7 +99,10 @@ UPDATE users
 SET
     updated_at = NOW(),
     email = $1,
-    hashed_password = $2
+    hashed_password = $2,
+    email_verified_at = CASE
+        WHEN email = $1 THEN email_verified_at
+    END
 WHERE id = $3
 RETURNING *;
 -- name: DeleteChirp :exec
@@ -86,4 +114,72 @@ SET
     is_chirpy_red = true,
     updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    updated_at = NOW(),
+    handle = $1,
+    display_name = $2,
+    bio = $3,
+    location = $4
+WHERE id = $5
+RETURNING *;
+-- name: GetProfileByHandle :one
+SELECT id,
+    created_at,
+    handle,
+    display_name,
+    bio,
+    location,
+    is_chirpy_red,
+    (
+        SELECT COUNT(*)
+        FROM follows
+        WHERE followee_id = users.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*)
+        FROM follows
+        WHERE follower_id = users.id
+    ) AS following_count
+FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg(handle)::text);
+
+-- name: SearchUsers :many
+SELECT u.id,
+    u.handle,
+    u.display_name,
+    u.is_chirpy_red,
+    (LOWER(u.handle) = sqlc.arg(query)::text)::bool AS exact_match,
+    EXISTS (
+        SELECT 1
+        FROM follows f
+        WHERE f.follower_id = sqlc.narg(viewer_id)::uuid
+            AND f.followee_id = u.id
+    ) AS followed_by_me
+FROM users u
+WHERE (
+        LOWER(u.handle) LIKE sqlc.arg(prefix_pattern)::text
+        OR LOWER(u.display_name) LIKE sqlc.arg(prefix_pattern)::text
+        OR LOWER(u.handle) % sqlc.arg(query)::text
+        OR sqlc.arg(query)::text <% LOWER(u.display_name)
+    )
+    AND (
+        u.id = sqlc.narg(viewer_id)::uuid
+        OR u.shadow_banned_at IS NULL
+    )
+ORDER BY exact_match DESC,
+    followed_by_me DESC,
+    GREATEST(
+        similarity(LOWER(u.handle), sqlc.arg(query)::text),
+        word_similarity(sqlc.arg(query)::text, LOWER(u.display_name))
+    ) DESC,
+    LOWER(u.handle)
+LIMIT sqlc.arg(result_limit);
+-- name: SetUserRoleByEmail :one
+UPDATE users
+SET role = $1,
+    updated_at = NOW()
+WHERE email = $2
+RETURNING *;
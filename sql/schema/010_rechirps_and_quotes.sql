@@ -0,0 +1,16 @@
+-- +goose Up
+-- rechirp_of and quote_of deliberately have no foreign key: when the original
+-- is deleted the reference stays behind so clients can render a tombstone.
+ALTER TABLE chirps
+ADD COLUMN rechirp_of UUID,
+ADD COLUMN quote_of UUID;
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of)
+WHERE rechirp_of IS NOT NULL;
+
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN rechirp_of,
+DROP COLUMN quote_of;
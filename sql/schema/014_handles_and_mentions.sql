@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT;
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (LOWER(handle));
+
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+
+ALTER TABLE users
+DROP COLUMN handle;
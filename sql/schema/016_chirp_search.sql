@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE chirp_search (
+    chirp_id UUID PRIMARY KEY,
+    document TSVECTOR NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_search_document_idx ON chirp_search USING GIN (document);
+
+INSERT INTO chirp_search (chirp_id, document)
+SELECT id, to_tsvector('english', body)
+FROM chirps
+WHERE rechirp_of IS NULL;
+
+-- +goose Down
+DROP TABLE chirp_search;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE filtered_terms (
+    term TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO filtered_terms (term)
+VALUES ('kerfuffle'),
+    ('sharbert'),
+    ('fornax');
+
+-- +goose Down
+DROP TABLE filtered_terms;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE filtered_terms
+ADD COLUMN action TEXT NOT NULL DEFAULT 'mask' CHECK (action IN ('mask', 'reject', 'flag'));
+
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE chirp_flags (
+    chirp_id UUID NOT NULL,
+    term TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, term),
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+
+ALTER TABLE users
+DROP COLUMN is_admin;
+
+ALTER TABLE filtered_terms
+DROP COLUMN action;
@@ -0,0 +1,84 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    -- both survive the chirp being deleted, so a resolved report still shows
+    -- whose chirp it was about
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    chirp_author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- NULL when the report was raised by the word filter
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved')),
+    claimed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    claimed_at TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    resolution TEXT CHECK (resolution IN ('dismiss', 'delete_chirp', 'suspend_author'))
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at, id);
+
+CREATE UNIQUE INDEX reports_open_per_reporter_idx ON reports (reporter_id, chirp_id)
+WHERE status <> 'resolved';
+
+CREATE TABLE moderation_audit_log (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+    chirp_id UUID,
+    target_user_id UUID,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX moderation_audit_log_created_at_idx ON moderation_audit_log (created_at, id);
+
+-- chirps flagged by the word filter now wait in the same queue as reports
+INSERT INTO reports (id, created_at, updated_at, chirp_id, chirp_author_id, reason, details)
+SELECT gen_random_uuid(),
+    f.created_at,
+    f.created_at,
+    f.chirp_id,
+    c.user_id,
+    'filtered_term',
+    f.term
+FROM chirp_flags f
+    JOIN chirps c ON c.id = f.chirp_id;
+
+DROP TABLE chirp_flags;
+
+-- +goose Down
+CREATE TABLE chirp_flags (
+    chirp_id UUID NOT NULL,
+    term TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, term),
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at);
+
+INSERT INTO chirp_flags (chirp_id, term, created_at)
+SELECT chirp_id,
+    details,
+    MIN(created_at)
+FROM reports
+WHERE reason = 'filtered_term'
+    AND status <> 'resolved'
+    AND chirp_id IS NOT NULL
+GROUP BY chirp_id,
+    details;
+
+DROP TABLE moderation_audit_log;
+
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN shadow_banned_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN shadow_banned_at;
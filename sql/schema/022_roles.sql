@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+UPDATE users
+SET role = 'admin'
+WHERE is_admin;
+
+ALTER TABLE users
+DROP COLUMN is_admin;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- moderators lose their access, since there was nothing between the two
+UPDATE users
+SET is_admin = true
+WHERE role = 'admin';
+
+ALTER TABLE users
+DROP COLUMN role;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMP;
+
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- the address the token was sent to, so a token for an old address
+    -- can't verify a new one
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+
+ALTER TABLE users
+DROP COLUMN email_verified_at;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE totp_credentials (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    -- has to be readable to check codes, so unlike tokens it can't be hashed
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    -- NULL until the user proves their app works by entering a code
+    enabled_at TIMESTAMP,
+    -- the step of the last code accepted, so codes can't be replayed
+    last_step BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE recovery_codes (
+    code_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+-- +goose Down
+DROP TABLE recovery_codes;
+
+DROP TABLE totp_credentials;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- every refresh token belongs to a family: the chain of tokens rotated out of
+-- one sign in. Existing tokens each start their own.
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+ADD COLUMN rotated_at TIMESTAMP;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN family_id DROP DEFAULT;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE security_events (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX security_events_user_id_idx ON security_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE security_events;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN rotated_at,
+DROP COLUMN family_id;
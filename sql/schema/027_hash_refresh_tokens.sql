@@ -0,0 +1,15 @@
+-- +goose Up
+-- refresh tokens are kept as the hex SHA-256 of the token, as auth.HashToken
+-- computes it. Hashing the existing rows in place keeps everyone logged in.
+UPDATE refresh_tokens
+SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token TO token_hash;
+
+-- +goose Down
+-- the hashes can't be turned back into tokens, so going back logs everyone out
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token_hash TO token;
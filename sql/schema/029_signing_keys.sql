@@ -0,0 +1,11 @@
+-- +goose Up
+-- Ed25519 keys that sign access tokens. id is the key's RFC 7638 thumbprint,
+-- and private_key its 32 byte seed.
+CREATE TABLE signing_keys (
+    id TEXT PRIMARY KEY,
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE signing_keys;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- wrong codes entered since the last password sign in, so codes can't be
+-- guessed on one challenge token indefinitely
+ALTER TABLE totp_credentials
+ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE totp_credentials
+DROP COLUMN failed_attempts;
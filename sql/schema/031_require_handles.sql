@@ -0,0 +1,13 @@
+-- +goose Up
+-- accounts from before handles were asked for get one made from their id,
+-- which they can change like any other
+UPDATE users
+SET handle = 'user_' || SUBSTR(REPLACE(id::text, '-', ''), 1, 10)
+WHERE handle IS NULL;
+
+ALTER TABLE users
+ALTER COLUMN handle SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE users
+ALTER COLUMN handle DROP NOT NULL;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- a generated column can't fall behind the body the way a side table kept up
+-- by the app could, and edits re-index themselves
+ALTER TABLE chirps
+ADD COLUMN search_document TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_document_idx ON chirps USING GIN (search_document);
+
+DROP TABLE chirp_search;
+
+-- +goose Down
+CREATE TABLE chirp_search (
+    chirp_id UUID PRIMARY KEY,
+    document TSVECTOR NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_search_document_idx ON chirp_search USING GIN (document);
+
+INSERT INTO chirp_search (chirp_id, document)
+SELECT id, search_document
+FROM chirps
+WHERE rechirp_of IS NULL;
+
+ALTER TABLE chirps
+DROP COLUMN search_document;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- seeds are now sealed with JWT_KEY_ENCRYPTION_KEY, which the database never
+-- sees, so the plaintext ones can't be converted here. Dropping them makes
+-- the server generate a sealed key on start; access tokens signed with the
+-- old keys stop working and clients refresh as they would on expiry.
+DELETE FROM signing_keys;
+
+ALTER TABLE signing_keys
+RENAME COLUMN private_key TO encrypted_private_key;
+
+-- +goose Down
+DELETE FROM signing_keys;
+
+ALTER TABLE signing_keys
+RENAME COLUMN encrypted_private_key TO private_key;
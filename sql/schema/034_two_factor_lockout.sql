@@ -0,0 +1,10 @@
+-- +goose Up
+-- failed_attempts now counts wrong codes within a window ending at the last
+-- one, rather than since the last password sign in, so signing in again
+-- doesn't earn more guesses
+ALTER TABLE totp_credentials
+ADD COLUMN last_failed_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE totp_credentials
+DROP COLUMN last_failed_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- index the body's tsvector instead of storing it, so it stays out of every
+-- row read from chirps; searches have to use the same expression to hit it
+ALTER TABLE chirps
+DROP COLUMN search_document;
+
+CREATE INDEX chirps_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX chirps_search_idx;
+
+ALTER TABLE chirps
+ADD COLUMN search_document TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_document_idx ON chirps USING GIN (search_document);
@@ -0,0 +1,45 @@
+-- +goose Up
+-- keep each mention as it was written, so renaming a handle later doesn't
+-- change or unlink what older chirps show
+ALTER TABLE chirp_mentions
+ADD COLUMN handle TEXT,
+ADD COLUMN start_offset INTEGER,
+ADD COLUMN end_offset INTEGER;
+
+-- older rows only say who was mentioned, so place them at the first spot the
+-- user's current handle appears in the body. Users who have renamed since
+-- can't be placed; their mentions already weren't being linked and are dropped
+UPDATE chirp_mentions cm
+SET handle = SUBSTRING(c.body FROM m.pos + 1 FOR LENGTH(u.handle)),
+    start_offset = m.pos - 1,
+    end_offset = m.pos + LENGTH(u.handle)
+FROM chirps c,
+    users u,
+    LATERAL (SELECT STRPOS(LOWER(c.body), '@' || LOWER(u.handle)) AS pos) m
+WHERE c.id = cm.chirp_id
+    AND u.id = cm.user_id
+    AND m.pos > 0;
+
+DELETE FROM chirp_mentions
+WHERE start_offset IS NULL;
+
+ALTER TABLE chirp_mentions
+ALTER COLUMN handle SET NOT NULL,
+ALTER COLUMN start_offset SET NOT NULL,
+ALTER COLUMN end_offset SET NOT NULL,
+DROP CONSTRAINT chirp_mentions_pkey,
+ADD PRIMARY KEY (chirp_id, start_offset);
+
+-- +goose Down
+DELETE FROM chirp_mentions a
+USING chirp_mentions b
+WHERE a.chirp_id = b.chirp_id
+    AND a.user_id = b.user_id
+    AND a.start_offset > b.start_offset;
+
+ALTER TABLE chirp_mentions
+DROP CONSTRAINT chirp_mentions_pkey,
+DROP COLUMN handle,
+DROP COLUMN start_offset,
+DROP COLUMN end_offset,
+ADD PRIMARY KEY (chirp_id, user_id);
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+type userModerationParams struct {
+	Note string `json:"note"`
+}
+
+type userModerationReturn struct {
+	Id             uuid.UUID  `json:"id"`
+	Handle         string     `json:"handle,omitempty"`
+	SuspendedAt    *time.Time `json:"suspended_at"`
+	ShadowBannedAt *time.Time `json:"shadow_banned_at"`
+}
+
+// suspendAccount suspends a user and revokes their refresh tokens, so they
+// are signed out everywhere once their access tokens expire. It reports
+// whether the user exists.
+func suspendAccount(ctx context.Context, q *database.Queries, userID uuid.UUID) (bool, error) {
+	updated, err := q.SuspendUser(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("couldn't suspend user: %s", err)
+	}
+	if updated == 0 {
+		return false, nil
+	}
+
+	if err := q.RevokeUserRefreshTokens(ctx, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		return false, fmt.Errorf("couldn't revoke refresh tokens: %s", err)
+	}
+	return true, nil
+}
+
+func (cfg *apiConfig) suspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.moderateUser(w, r, "suspend_user", suspendAccount)
+}
+
+func (cfg *apiConfig) unsuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.moderateUser(w, r, "unsuspend_user", func(ctx context.Context, q *database.Queries, userID uuid.UUID) (bool, error) {
+		updated, err := q.UnsuspendUser(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("couldn't lift suspension: %s", err)
+		}
+		return updated > 0, nil
+	})
+}
+
+func (cfg *apiConfig) shadowBanUser(w http.ResponseWriter, r *http.Request) {
+	cfg.moderateUser(w, r, "shadow_ban_user", func(ctx context.Context, q *database.Queries, userID uuid.UUID) (bool, error) {
+		updated, err := q.ShadowBanUser(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("couldn't shadow-ban user: %s", err)
+		}
+		return updated > 0, nil
+	})
+}
+
+func (cfg *apiConfig) unshadowBanUser(w http.ResponseWriter, r *http.Request) {
+	cfg.moderateUser(w, r, "unshadow_ban_user", func(ctx context.Context, q *database.Queries, userID uuid.UUID) (bool, error) {
+		updated, err := q.UnshadowBanUser(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("couldn't lift shadow ban: %s", err)
+		}
+		return updated > 0, nil
+	})
+}
+
+// moderateUser runs apply against the user named in the path and records it
+// in the audit log under action. apply reports whether the user exists.
+func (cfg *apiConfig) moderateUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	apply func(ctx context.Context, q *database.Queries, userID uuid.UUID) (bool, error),
+) {
+	moderatorID := callerID(r)
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid user id"})
+		return
+	}
+	if userID == moderatorID {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "you can't moderate your own account"})
+		return
+	}
+
+	// the note is optional, so an empty body is fine
+	params := userModerationParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, err := qtx.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load user: %s", err))
+		return
+	}
+	// otherwise a moderator could lock out the admins who oversee them
+	if auth.Role(target.Role).IsStaff() {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "moderators and admins can't be moderated; take their role away first"})
+		return
+	}
+
+	found, err := apply(r.Context(), qtx, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "user not found"})
+		return
+	}
+
+	if err := audit(r.Context(), qtx, database.CreateAuditLogEntryParams{
+		ActorID:      uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:       action,
+		TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+		Details:      strings.TrimSpace(params.Note),
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load user: %s", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit %s: %s", action, err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userModerationReturn{
+		Id:             user.ID,
+		Handle:         user.Handle,
+		SuspendedAt:    nullTimePtr(user.SuspendedAt),
+		ShadowBannedAt: nullTimePtr(user.ShadowBannedAt),
+	})
+}
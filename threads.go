@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/database"
+)
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	// maxThreadReplies caps how many descendants a single thread request loads,
+	// so a viral chirp can't turn one request into an unbounded query.
+	maxThreadReplies = 500
+)
+
+type threadNode struct {
+	returnVals
+	Replies []*threadNode `json:"replies"`
+}
+
+type threadReturn struct {
+	Ancestors []returnVals `json:"ancestors"`
+	Chirp     *threadNode  `json:"chirp"`
+}
+
+func (cfg *apiConfig) getChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpid"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("incorrect id format"))
+		return
+	}
+
+	depth := defaultThreadDepth
+	if rawDepth := r.URL.Query().Get("depth"); rawDepth != "" {
+		depth, err = strconv.Atoi(rawDepth)
+		if err != nil || depth < 0 {
+			respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "depth must be a non-negative integer"})
+			return
+		}
+		if depth > maxThreadDepth {
+			depth = maxThreadDepth
+		}
+	}
+
+	viewerID := cfg.optionalUserID(r)
+
+	chirp, err := cfg.dbQueries.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpId,
+		ViewerID: viewerID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusNotFound, errorReturn{Error: "chirp not found"})
+		return
+	} else if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, errorReturn{Error: "query failed"})
+		return
+	}
+
+	// hidden ancestors are skipped and hidden replies are pruned along with
+	// everything below them
+	ancestorRows, err := cfg.dbQueries.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  chirpId,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load ancestors: %s", err))
+		return
+	}
+
+	var descendantRows []database.GetChirpDescendantsRow
+	if depth > 0 {
+		descendantRows, err = cfg.dbQueries.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+			ChirpID:    chirpId,
+			ViewerID:   viewerID,
+			MaxDepth:   int32(depth),
+			MaxReplies: maxThreadReplies,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load replies: %s", err))
+			return
+		}
+	}
+
+	// hydrate everything in one batch: ancestors, then the chirp, then replies
+	chirps := make([]database.Chirp, 0, len(ancestorRows)+1+len(descendantRows))
+	for _, v := range ancestorRows {
+		chirps = append(chirps, database.Chirp(v))
+	}
+	chirps = append(chirps, chirp)
+	for _, v := range descendantRows {
+		chirps = append(chirps, database.Chirp{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			Body:      v.Body,
+			UserID:    v.UserID,
+			InReplyTo: v.InReplyTo,
+			RechirpOf: v.RechirpOf,
+			QuoteOf:   v.QuoteOf,
+		})
+	}
+
+	hydrated, err := cfg.chirpsToReturn(r.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ancestors := hydrated[:len(ancestorRows)]
+	root := &threadNode{returnVals: hydrated[len(ancestorRows)], Replies: []*threadNode{}}
+
+	// descendants come back ordered by depth, so every parent is already in
+	// the map by the time its replies are reached
+	nodes := map[uuid.UUID]*threadNode{root.Id: root}
+	for _, v := range hydrated[len(ancestorRows)+1:] {
+		node := &threadNode{returnVals: v, Replies: []*threadNode{}}
+		nodes[v.Id] = node
+		if parent, ok := nodes[v.InReplyTo.UUID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, threadReturn{Ancestors: ancestors, Chirp: root})
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// twoFactorChallengeTTL is how long someone has to enter a code after
+	// getting their password right.
+	twoFactorChallengeTTL = 5 * time.Minute
+	// maxTwoFactorAttempts is how many wrong codes can be entered within
+	// twoFactorLockout of each other before codes stop being checked. Only
+	// a right code, or twoFactorLockout passing, lets them be tried again.
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+	recoveryCodeCount    = 10
+	totpIssuer           = "Chirpy"
+	qrCodeSize           = 256
+)
+
+type twoFactorCodeParams struct {
+	Code string `json:"code"`
+}
+
+type disableTwoFactorParams struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type twoFactorLoginParams struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type twoFactorChallengeReturn struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+type twoFactorEnrollReturn struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	// QRCodePNG is the URI as a QR code, base64 encoded like any []byte
+	QRCodePNG []byte `json:"qr_code_png"`
+}
+
+type recoveryCodesReturn struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery
+// code for credential, and uses it up. q has to be a transaction that locked
+// credential with GetTOTPCredentialForUpdate.
+func checkSecondFactor(ctx context.Context, q *database.Queries, credential database.TotpCredential, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := auth.MatchTOTP(credential.Secret, code, time.Now()); ok {
+		// a code can only be used once, even within its period
+		if step <= credential.LastStep {
+			return false, nil
+		}
+		if err := q.SetTOTPLastStep(ctx, database.SetTOTPLastStepParams{
+			LastStep: step,
+			UserID:   credential.UserID,
+		}); err != nil {
+			return false, fmt.Errorf("couldn't save code use: %s", err)
+		}
+		return true, nil
+	}
+
+	used, err := q.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		UserID:   credential.UserID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("couldn't check recovery code: %s", err)
+	}
+	return used > 0, nil
+}
+
+// errTwoFactorLocked is returned by verifySecondFactor while too many wrong
+// codes have been entered recently.
+var errTwoFactorLocked = errors.New("too many invalid codes; try again later")
+
+// verifySecondFactor is checkSecondFactor with a limit on guessing: wrong
+// codes are counted, and once there are too many no code is checked until
+// twoFactorLockout passes. A wrong code is only counted if the caller commits
+// q's transaction, which it has to do even though it refuses the request.
+func verifySecondFactor(ctx context.Context, q *database.Queries, credential database.TotpCredential, code string) (bool, error) {
+	locked, err := q.IsTOTPLockedOut(ctx, database.IsTOTPLockedOutParams{
+		MaxAttempts:   maxTwoFactorAttempts,
+		WindowSeconds: twoFactorLockout.Seconds(),
+		UserID:        credential.UserID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("couldn't check failed codes: %s", err)
+	}
+	if locked {
+		return false, errTwoFactorLocked
+	}
+
+	valid, err := checkSecondFactor(ctx, q, credential, code)
+	if err != nil {
+		return false, err
+	}
+
+	if !valid {
+		if err := q.RecordTOTPFailure(ctx, database.RecordTOTPFailureParams{
+			WindowSeconds: twoFactorLockout.Seconds(),
+			UserID:        credential.UserID,
+		}); err != nil {
+			return false, fmt.Errorf("couldn't record failed code: %s", err)
+		}
+		return false, nil
+	}
+
+	if err := q.ResetTOTPFailures(ctx, credential.UserID); err != nil {
+		return false, fmt.Errorf("couldn't reset failed codes: %s", err)
+	}
+	return true, nil
+}
+
+// lockTwoFactor loads the caller's TOTP credential inside tx. It fails with
+// 409 when two-factor isn't on.
+func lockTwoFactor(ctx context.Context, q *database.Queries, user database.User) (database.TotpCredential, int, error) {
+	credential, err := q.GetTOTPCredentialForUpdate(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !credential.EnabledAt.Valid) {
+		return credential, http.StatusConflict, errors.New("two-factor authentication is not on")
+	} else if err != nil {
+		return credential, http.StatusInternalServerError, fmt.Errorf("couldn't load two-factor settings: %s", err)
+	}
+	return credential, http.StatusOK, nil
+}
+
+func (cfg *apiConfig) enrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+
+	credential, err := cfg.dbQueries.GetTOTPCredential(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load two-factor settings: %s", err))
+		return
+	}
+	if err == nil && credential.EnabledAt.Valid {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "two-factor authentication is already on"})
+		return
+	}
+
+	// enrolling again before confirming replaces the secret, so only the
+	// latest QR code works
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := cfg.dbQueries.StartTOTPEnrollment(r.Context(), database.StartTOTPEnrollmentParams{
+		UserID: user.ID,
+		Secret: secret,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't save two-factor secret: %s", err))
+		return
+	}
+
+	uri := auth.TOTPURI(secret, totpIssuer, user.Email)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't draw QR code: %s", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, twoFactorEnrollReturn{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCodePNG:  png,
+	})
+}
+
+func (cfg *apiConfig) confirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+
+	params := twoFactorCodeParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	credential, err := qtx.GetTOTPCredentialForUpdate(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "start enrolling first"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load two-factor settings: %s", err))
+		return
+	}
+	if credential.EnabledAt.Valid {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "two-factor authentication is already on"})
+		return
+	}
+
+	// a working code shows the app was set up right before the account
+	// starts depending on it
+	step, ok := auth.MatchTOTP(credential.Secret, strings.TrimSpace(params.Code), time.Now())
+	if !ok {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid code"})
+		return
+	}
+
+	if err := qtx.EnableTOTP(r.Context(), database.EnableTOTPParams{
+		LastStep: step,
+		UserID:   user.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't turn on two-factor: %s", err))
+		return
+	}
+
+	if err := qtx.DeleteRecoveryCodes(r.Context(), user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't clear recovery codes: %s", err))
+		return
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := auth.MakeRecoveryCode()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+			UserID:   user.ID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't save recovery code: %s", err))
+			return
+		}
+		codes = append(codes, code)
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit two-factor: %s", err))
+		return
+	}
+
+	// this is the only time the codes are shown; only hashes are kept
+	respondWithJSON(w, http.StatusOK, recoveryCodesReturn{RecoveryCodes: codes})
+}
+
+func (cfg *apiConfig) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+
+	params := disableTwoFactorParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	// a stolen access token alone isn't enough to turn it off
+	if err := auth.CheckPasswordHash(params.Password, user.HashedPassword); err != nil {
+		respondWithJSON(w, http.StatusUnauthorized, errorReturn{Error: "incorrect password or code"})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	credential, status, err := lockTwoFactor(r.Context(), qtx, user)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		respondWithJSON(w, status, errorReturn{Error: err.Error()})
+		return
+	}
+
+	valid, err := verifySecondFactor(r.Context(), qtx, credential, params.Code)
+	if errors.Is(err, errTwoFactorLocked) {
+		respondWithJSON(w, http.StatusTooManyRequests, errorReturn{Error: err.Error()})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !valid {
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit failed code: %s", err))
+			return
+		}
+		respondWithJSON(w, http.StatusUnauthorized, errorReturn{Error: "incorrect password or code"})
+		return
+	}
+
+	if err := qtx.DeleteTOTPCredential(r.Context(), user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't turn off two-factor: %s", err))
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't clear recovery codes: %s", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit two-factor: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) logInTwoFactor(w http.ResponseWriter, r *http.Request) {
+	params := twoFactorLoginParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: fmt.Sprintf("Couldn't decode request body: %s", err)})
+		return
+	}
+
+	userID, err := cfg.tokens.ValidateChallenge(params.ChallengeToken)
+	if err != nil {
+		respondWithAuthError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithAuthError(w, http.StatusUnauthorized, fmt.Errorf("%w: user %s", auth.ErrUnknownSubject, userID))
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't look up user: %s", err))
+		return
+	}
+	if user.SuspendedAt.Valid {
+		respondWithJSON(w, http.StatusForbidden, errorReturn{Error: errAccountSuspended.Error()})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	credential, status, err := lockTwoFactor(r.Context(), qtx, user)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, err)
+		return
+	} else if err != nil {
+		// two-factor was turned off since the challenge was issued
+		respondWithJSON(w, http.StatusUnauthorized, errorReturn{Error: "sign in again"})
+		return
+	}
+	valid, err := verifySecondFactor(r.Context(), qtx, credential, params.Code)
+	if errors.Is(err, errTwoFactorLocked) {
+		respondWithJSON(w, http.StatusTooManyRequests, errorReturn{Error: err.Error()})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !valid {
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit failed code: %s", err))
+			return
+		}
+		respondWithJSON(w, http.StatusUnauthorized, errorReturn{Error: "invalid code"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit sign in: %s", err))
+		return
+	}
+
+	cfg.signIn(w, r, user)
+}
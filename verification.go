@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathnael-desta/chirpy/internal/auth"
+	"github.com/nathnael-desta/chirpy/internal/database"
+	"github.com/nathnael-desta/chirpy/internal/mailer"
+)
+
+// emailVerificationTTL is how long a verification link works for.
+const emailVerificationTTL = 24 * time.Hour
+
+type emailVerifiedReturn struct {
+	Email           string    `json:"email"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+}
+
+// issueEmailVerification saves a new token for confirming that userID owns
+// email and returns it. Any earlier tokens stop working. Callers send it with
+// sendVerificationEmail once the token has been committed.
+func issueEmailVerification(ctx context.Context, q *database.Queries, userID uuid.UUID, email string) (string, error) {
+	if err := q.ExpireEmailVerifications(ctx, userID); err != nil {
+		return "", fmt.Errorf("couldn't expire verification tokens: %s", err)
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}); err != nil {
+		return "", fmt.Errorf("couldn't save verification token: %s", err)
+	}
+	return token, nil
+}
+
+func (cfg *apiConfig) sendVerificationEmail(email, token string) {
+	link := cfg.baseURL + "/api/verify-email?token=" + url.QueryEscape(token)
+	cfg.sendMail(mailer.Message{
+		To:      email,
+		Subject: "Confirm your email address for Chirpy",
+		Body: fmt.Sprintf("Open this link within %d hours to confirm this is your email address:\n\n%s\n\n"+
+			"If you didn't sign up for Chirpy or change your email, you can ignore this email.\n",
+			int(emailVerificationTTL.Hours()), link),
+	})
+}
+
+// canPost reports whether the email policy lets user post chirps.
+func (cfg *apiConfig) canPost(user database.User) bool {
+	return !cfg.requireVerifiedEmail || user.EmailVerifiedAt.Valid
+}
+
+func (cfg *apiConfig) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "token is required"})
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't start transaction: %s", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	verification, err := qtx.UseEmailVerification(r.Context(), auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "invalid or expired verification token"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't check verification token: %s", err))
+		return
+	}
+
+	// only verifies the address if the account still uses the one the link
+	// was sent to
+	verifiedAt, err := qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    verification.UserID,
+		Email: verification.Email,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusBadRequest, errorReturn{Error: "this link is for an email address the account no longer uses"})
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't verify email: %s", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("couldn't commit verification: %s", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, emailVerifiedReturn{
+		Email:           verification.Email,
+		EmailVerifiedAt: verifiedAt.Time,
+	})
+}
+
+func (cfg *apiConfig) resendVerification(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		respondWithJSON(w, http.StatusConflict, errorReturn{Error: "email is already verified"})
+		return
+	}
+
+	token, err := issueEmailVerification(r.Context(), cfg.dbQueries, user.ID, user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	cfg.sendVerificationEmail(user.Email, token)
+
+	w.WriteHeader(http.StatusAccepted)
+}